@@ -0,0 +1,207 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// tokenExpiryLeeway is how long before its expiry an access token is renewed.
+const tokenExpiryLeeway = 30 * time.Second
+
+type tokenResponse struct {
+	Access  string `json:"access"`
+	Refresh string `json:"refresh"`
+}
+
+// Do sends an authenticated request. The access token is renewed shortly
+// before it expires, and a request rejected with 401 is retried once with a
+// freshly renewed token.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	token, err := c.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := c.httpClient.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	// The body of the first attempt has been consumed, so the request can
+	// only be replayed if it can produce a fresh copy of it.
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	token, err = c.renewToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	retry := req.Clone(ctx)
+	if req.GetBody != nil {
+		retry.Body, err = req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+	}
+	retry.Header.Set("Authorization", "Bearer "+token)
+
+	return c.httpClient.Do(retry)
+}
+
+// Login authenticates with the username and password and stores the
+// returned token pair.
+func (c *Client) Login(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.login(ctx)
+}
+
+// token returns a valid access token, renewing it first if it is about to
+// expire.
+func (c *Client) token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.accessToken != "" && (c.expiresAt.IsZero() || time.Until(c.expiresAt) > tokenExpiryLeeway) {
+		return c.accessToken, nil
+	}
+
+	if err := c.refresh(ctx); err != nil {
+		return "", err
+	}
+
+	return c.accessToken, nil
+}
+
+// renewToken replaces a rejected access token. When several requests are
+// rejected at the same time only the first one renews the token, the others
+// pick up the result.
+func (c *Client) renewToken(ctx context.Context, rejected string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.accessToken != rejected {
+		return c.accessToken, nil
+	}
+
+	if err := c.refresh(ctx); err != nil {
+		return "", err
+	}
+
+	return c.accessToken, nil
+}
+
+// refresh obtains a new access token with the refresh token, and falls back
+// to logging in again when there is no refresh token or it has expired.
+// The caller must hold c.mu.
+func (c *Client) refresh(ctx context.Context) error {
+	if c.refreshToken == "" {
+		return c.login(ctx)
+	}
+
+	result, err := c.postAuth(ctx, "refresh token", "/auth/refresh", map[string]string{
+		"refresh": c.refreshToken,
+	})
+	if err != nil || result.Access == "" {
+		return c.login(ctx)
+	}
+
+	c.setTokens(result)
+	return nil
+}
+
+// login authenticates with the configured credentials. The caller must hold
+// c.mu.
+func (c *Client) login(ctx context.Context) error {
+	result, err := c.postAuth(ctx, "authenticate", "/auth/login", map[string]string{
+		"username": c.Username,
+		"password": c.Password,
+	})
+	if err != nil {
+		return err
+	}
+
+	if result.Access == "" {
+		return errors.New("failed to get token")
+	}
+
+	c.setTokens(result)
+	return nil
+}
+
+func (c *Client) postAuth(ctx context.Context, op, path string, payload map[string]string) (*tokenResponse, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(retrySafe(ctx), "POST", c.MainAPIURL+path, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newError(op, resp)
+	}
+
+	var result tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// setTokens stores a token pair. A refresh response without a rotated
+// refresh token keeps the current one. The caller must hold c.mu.
+func (c *Client) setTokens(result *tokenResponse) {
+	c.accessToken = result.Access
+	if result.Refresh != "" {
+		c.refreshToken = result.Refresh
+	}
+	c.expiresAt = tokenExpiry(result.Access)
+}
+
+// tokenExpiry reads the "exp" claim of a JWT without verifying it. A zero
+// time is returned when the token carries no readable expiry, in which case
+// the token is only renewed after the API rejects it.
+func tokenExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}
+	}
+
+	var claims struct {
+		Exp int64 `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(claims.Exp, 0)
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Batch is a class of a course, called a batch by the API.
+type Batch struct {
+	ID            int    `json:"id"`
+	Name          string `json:"batch"`
+	StartDate     string `json:"start_date"`
+	EndDate       string `json:"end_date"`
+	GroupType     int    `json:"group_type"`
+	IsLastBatch   bool   `json:"is_last_batch"`
+	IsEnrollPass  bool   `json:"is_enroll_pass"`
+	IsCertificate bool   `json:"is_certificate"`
+	IsSchedule    bool   `json:"is_schedule"`
+	IsActive      bool   `json:"is_active"`
+	CourseID      int    `json:"course"`
+	Course        Course `json:"course_data"`
+}
+
+// BatchRequest holds the writable fields of a batch.
+type BatchRequest struct {
+	Name          string `json:"batch"`
+	StartDate     string `json:"start_date"`
+	EndDate       string `json:"end_date"`
+	GroupType     int    `json:"group_type"`
+	IsLastBatch   bool   `json:"is_last_batch"`
+	IsEnrollPass  bool   `json:"is_enroll_pass"`
+	IsCertificate bool   `json:"is_certificate"`
+	IsSchedule    bool   `json:"is_schedule"`
+	CourseID      int    `json:"course"`
+}
+
+// ListBatches returns the batches of a course. The list is served from the
+// client's cache until the client changes a batch.
+func (c *Client) ListBatches(ctx context.Context, courseID int) ([]Batch, error) {
+	value, err := c.cache.get(ctx, fmt.Sprintf("batches/%d", courseID), func() (interface{}, error) {
+		url := fmt.Sprintf("%s/admin/batchs/?course_id=%d", c.APIURL, courseID)
+
+		var batches []Batch
+		if err := c.do(ctx, "get batches", "GET", url, nil, &batches, http.StatusOK); err != nil {
+			return nil, err
+		}
+		return batches, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]Batch(nil), value.([]Batch)...), nil
+}
+
+// GetBatch returns a single batch.
+func (c *Client) GetBatch(ctx context.Context, id int) (*Batch, error) {
+	var batch Batch
+	if err := c.do(ctx, "read class", "GET", c.batchURL(id), nil, &batch, http.StatusOK); err != nil {
+		return nil, err
+	}
+
+	return &batch, nil
+}
+
+// CreateBatch creates a batch and returns it. Creating is not idempotent,
+// so after a transient failure the batch is looked up by name before the
+// request is sent again.
+func (c *Client) CreateBatch(ctx context.Context, input BatchRequest) (*Batch, error) {
+	for attempt := 0; ; attempt++ {
+		var batch Batch
+		err := c.do(ctx, "create class", "POST", c.APIURL+"/admin/batchs/", input, &batch, http.StatusCreated)
+		c.cache.invalidate("batches/")
+		if err == nil {
+			return &batch, nil
+		}
+
+		if attempt >= c.retry.maxRetries || !isTransient(err) {
+			return nil, err
+		}
+
+		// The batch may have been created even though the response was
+		// lost. Give up if that cannot be verified.
+		existing, findErr := c.findBatch(ctx, input.CourseID, input.Name)
+		if findErr != nil {
+			return nil, err
+		}
+		if existing != nil {
+			return existing, nil
+		}
+
+		retryAfter := ""
+		var apiErr *Error
+		if errors.As(err, &apiErr) {
+			retryAfter = apiErr.retryAfter
+		}
+
+		if err := sleep(ctx, c.retry.backoff(attempt, retryAfter)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// UpdateBatch replaces the writable fields of a batch.
+func (c *Client) UpdateBatch(ctx context.Context, id int, input BatchRequest) error {
+	defer c.cache.invalidate("batches/")
+	return c.do(ctx, "update class", "PUT", c.batchURL(id), input, nil, http.StatusOK)
+}
+
+// DeleteBatch deletes a batch.
+func (c *Client) DeleteBatch(ctx context.Context, id int) error {
+	defer c.cache.invalidate("batches/")
+	return c.do(ctx, "delete class", "DELETE", c.batchURL(id), nil, nil, http.StatusNoContent)
+}
+
+// ChangeBatchStatus activates or deactivates a batch without broadcasting
+// the change to its users. It sets the status to a fixed value, so it is
+// retried on transient failures.
+func (c *Client) ChangeBatchStatus(ctx context.Context, id int, isActive bool) error {
+	payload := map[string]interface{}{
+		"is_broadcast": false,
+		"is_active":    isActive,
+	}
+
+	defer c.cache.invalidate("batches/")
+	return c.do(retrySafe(ctx), "change class status", "POST", c.batchURL(id)+"change_status/", payload, nil, http.StatusOK)
+}
+
+// findBatch returns the batch of a course with the given name, or nil when
+// there is none.
+func (c *Client) findBatch(ctx context.Context, courseID int, name string) (*Batch, error) {
+	batches, err := c.ListBatches(ctx, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range batches {
+		if batches[i].Name == name {
+			return &batches[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (c *Client) batchURL(id int) string {
+	return fmt.Sprintf("%s/admin/batchs/%d/", c.APIURL, id)
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// lookupCache remembers the results of list calls for the lifetime of a
+// Client. Concurrent lookups of the same key share a single request, and
+// failed lookups are not remembered.
+type lookupCache struct {
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// get returns the cached value of key, calling fetch to load it when it is
+// not cached yet. fetch runs with the context of the caller that started the
+// lookup; callers waiting for it stop waiting when their own ctx is done, and
+// start the lookup again if it was cancelled by the context of its starter.
+func (c *lookupCache) get(ctx context.Context, key string, fetch func() (interface{}, error)) (interface{}, error) {
+	for {
+		c.mu.Lock()
+		entry, ok := c.entries[key]
+		if !ok {
+			break
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		if !isContextError(entry.err) || ctx.Err() != nil {
+			return entry.value, entry.err
+		}
+	}
+
+	if c.entries == nil {
+		c.entries = make(map[string]*cacheEntry)
+	}
+	entry := &cacheEntry{done: make(chan struct{})}
+	c.entries[key] = entry
+	c.mu.Unlock()
+
+	entry.value, entry.err = fetch()
+
+	// Forget a failed lookup before waking the waiters, so those that start
+	// it again do not find it.
+	if entry.err != nil {
+		c.mu.Lock()
+		if c.entries[key] == entry {
+			delete(c.entries, key)
+		}
+		c.mu.Unlock()
+	}
+	close(entry.done)
+
+	return entry.value, entry.err
+}
+
+// peek returns the value of key if it has been loaded, without loading it
+// or waiting for a lookup in flight.
+func (c *lookupCache) peek(key string) (interface{}, bool) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+
+	if !ok {
+		return nil, false
+	}
+
+	select {
+	case <-entry.done:
+		return entry.value, entry.err == nil
+	default:
+		return nil, false
+	}
+}
+
+// put stores a value loaded outside of get, for example by a request that
+// looks up several keys at once.
+func (c *lookupCache) put(key string, value interface{}) {
+	entry := &cacheEntry{done: make(chan struct{}), value: value}
+	close(entry.done)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil {
+		c.entries = make(map[string]*cacheEntry)
+	}
+	c.entries[key] = entry
+}
+
+// invalidate forgets every key starting with prefix. Lookups in flight
+// complete, but their result is not remembered.
+func (c *lookupCache) invalidate(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
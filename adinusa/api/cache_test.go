@@ -0,0 +1,185 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLookupCacheSharesConcurrentFetches(t *testing.T) {
+	var c lookupCache
+	var calls int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	fetch := func() (interface{}, error) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+		}
+		<-release
+		return "value", nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]interface{}, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			value, err := c.get(context.Background(), "key", fetch)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			results[i] = value
+		}(i)
+	}
+
+	<-started
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("fetch called %d times, want 1", calls)
+	}
+	for i, value := range results {
+		if value != "value" {
+			t.Errorf("result %d = %v, want value", i, value)
+		}
+	}
+}
+
+func TestLookupCacheRestartsLookupCancelledByStarter(t *testing.T) {
+	var c lookupCache
+	var calls int32
+
+	starterCtx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	starterDone := make(chan error)
+	go func() {
+		_, err := c.get(starterCtx, "key", func() (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			close(started)
+			<-starterCtx.Done()
+			return nil, starterCtx.Err()
+		})
+		starterDone <- err
+	}()
+	<-started
+
+	waiterDone := make(chan interface{})
+	go func() {
+		value, err := c.get(context.Background(), "key", func() (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			return "waiter", nil
+		})
+		if err != nil {
+			t.Errorf("waiter got error %v, want the result of its own fetch", err)
+		}
+		waiterDone <- value
+	}()
+
+	// Give the waiter time to start waiting for the starter's lookup.
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	if err := <-starterDone; !errors.Is(err, context.Canceled) {
+		t.Errorf("starter got %v, want context.Canceled", err)
+	}
+	if value := <-waiterDone; value != "waiter" {
+		t.Errorf("waiter got %v, want waiter", value)
+	}
+	if calls != 2 {
+		t.Errorf("fetch called %d times, want 2", calls)
+	}
+}
+
+func TestLookupCacheWaiterStopsOnItsOwnContext(t *testing.T) {
+	var c lookupCache
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	go c.get(context.Background(), "key", func() (interface{}, error) {
+		close(started)
+		<-release
+		return "value", nil
+	})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := c.get(ctx, "key", func() (interface{}, error) {
+		t.Error("waiter fetched while a lookup was in flight")
+		return nil, nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestLookupCacheDoesNotCacheFailures(t *testing.T) {
+	var c lookupCache
+	var calls int
+
+	fetch := func() (interface{}, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("boom")
+		}
+		return "value", nil
+	}
+
+	if _, err := c.get(context.Background(), "key", fetch); err == nil {
+		t.Fatal("first get succeeded, want the fetch error")
+	}
+
+	value, err := c.get(context.Background(), "key", fetch)
+	if err != nil || value != "value" {
+		t.Errorf("second get = %v, %v, want value", value, err)
+	}
+	if calls != 2 {
+		t.Errorf("fetch called %d times, want 2", calls)
+	}
+}
+
+func TestLookupCacheDropsResultFinishedAfterInvalidate(t *testing.T) {
+	var c lookupCache
+	var calls int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	done := make(chan interface{})
+	go func() {
+		value, _ := c.get(context.Background(), "batches/1", func() (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			close(started)
+			<-release
+			return "stale", nil
+		})
+		done <- value
+	}()
+	<-started
+
+	c.invalidate("batches/")
+	close(release)
+
+	// The lookup in flight still completes for its caller.
+	if value := <-done; value != "stale" {
+		t.Errorf("in-flight get = %v, want stale", value)
+	}
+
+	value, err := c.get(context.Background(), "batches/1", func() (interface{}, error) {
+		atomic.AddInt32(&calls, 1)
+		return "fresh", nil
+	})
+	if err != nil || value != "fresh" {
+		t.Errorf("get after invalidate = %v, %v, want fresh", value, err)
+	}
+	if calls != 2 {
+		t.Errorf("fetch called %d times, want 2", calls)
+	}
+}
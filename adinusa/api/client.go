@@ -0,0 +1,147 @@
+// Package api is a client for the Adinusa admin API used by the Terraform
+// provider. It can be used on its own by any tool that manages Adinusa
+// courses, classes and enrollments.
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Config holds the settings used to build a Client.
+type Config struct {
+	// MainAPIURL is the base URL of the authentication API.
+	MainAPIURL string
+	// APIURL is the base URL of the academy or pro training API.
+	APIURL   string
+	Username string
+	Password string
+	// HTTPClient sends the requests. A default client is used when nil.
+	HTTPClient *http.Client
+
+	// RequestTimeout bounds every attempt of a request, including reading
+	// the response. Zero disables the timeout; the context passed to each
+	// call still applies.
+	RequestTimeout time.Duration
+
+	// MaxRetries is how many times a request that failed with a transient
+	// error is sent again. Zero disables retries.
+	MaxRetries int
+	// RetryMinWait and RetryMaxWait bound the exponential backoff between
+	// retries.
+	RetryMinWait time.Duration
+	RetryMaxWait time.Duration
+
+	// RequestsPerSecond caps the rate of requests sent to the API. Zero
+	// disables the rate limit.
+	RequestsPerSecond float64
+	// MaxConcurrentRequests caps the number of requests in flight. Zero
+	// disables the cap.
+	MaxConcurrentRequests int
+}
+
+// Client talks to the Adinusa API. It is safe for concurrent use.
+type Client struct {
+	MainAPIURL string
+	APIURL     string
+	Username   string
+	Password   string
+
+	httpClient *http.Client
+	retry      *retryTransport
+
+	cache lookupCache
+
+	mu           sync.Mutex
+	accessToken  string
+	refreshToken string
+	expiresAt    time.Time
+}
+
+// NewClient returns a Client for the given configuration. It does not log
+// in; call Login to check the credentials up front, otherwise the first
+// request logs in.
+func NewClient(config Config) *Client {
+	httpClient := &http.Client{}
+	if config.HTTPClient != nil {
+		*httpClient = *config.HTTPClient
+	}
+
+	// Every retry attempt counts against the limits and gets its own
+	// timeout.
+	timeout := newTimeoutTransport(httpClient.Transport, config)
+	limit := newLimitTransport(timeout, config)
+	retry := newRetryTransport(limit, config)
+	httpClient.Transport = retry
+
+	return &Client{
+		MainAPIURL: config.MainAPIURL,
+		APIURL:     config.APIURL,
+		Username:   config.Username,
+		Password:   config.Password,
+		httpClient: httpClient,
+		retry:      retry,
+	}
+}
+
+// InvalidateCache forgets the course and batch lists remembered by the
+// client. The client already does this after changing a batch itself; call
+// it when batches may have been changed by someone else.
+func (c *Client) InvalidateCache() {
+	c.cache.invalidate("")
+}
+
+// do sends a JSON request to the API and decodes the response into out
+// when out is not nil, or copies the raw body when out is a *[]byte. Any
+// status other than expected is returned as an *Error describing op.
+func (c *Client) do(ctx context.Context, op, method, url string, in, out interface{}, expected int) error {
+	var body io.Reader
+	if in != nil {
+		payload, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewBuffer(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expected {
+		return newError(op, resp)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	// Bodies that are not always JSON are returned as is.
+	if raw, ok := out.(*[]byte); ok {
+		*raw, err = io.ReadAll(resp.Body)
+		return err
+	}
+
+	// Some endpoints answer with an empty body.
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil && err != io.EOF {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// Course is a course in the Adinusa catalog.
+type Course struct {
+	ID          int       `json:"id"`
+	Title       string    `json:"title"`
+	Slug        string    `json:"slug"`
+	Description string    `json:"description"`
+	Category    *Category `json:"category"`
+	// IsActive is nil when the API does not report the state of a course.
+	IsActive *bool `json:"is_active"`
+}
+
+// Active reports whether the course is active. Courses whose state is not
+// reported are listed in the catalog and therefore considered active.
+func (c Course) Active() bool {
+	return c.IsActive == nil || *c.IsActive
+}
+
+// Category is the category of a course. Depending on the endpoint the API
+// sends it as an ID, a name or an object.
+type Category struct {
+	ID   int
+	Name string
+}
+
+func (c *Category) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case float64:
+		c.ID = int(v)
+	case string:
+		c.Name = v
+	case map[string]interface{}:
+		if id, ok := v["id"].(float64); ok {
+			c.ID = int(id)
+		}
+		if name, ok := v["name"].(string); ok {
+			c.Name = name
+		} else if title, ok := v["title"].(string); ok {
+			c.Name = title
+		}
+	}
+
+	return nil
+}
+
+// ListCourses returns every course in the catalog. The list is fetched once
+// and then served from the client's cache.
+func (c *Client) ListCourses(ctx context.Context) ([]Course, error) {
+	value, err := c.cache.get(ctx, "courses", func() (interface{}, error) {
+		var courses []Course
+		if err := c.do(ctx, "get courses", "GET", c.APIURL+"/courses/", nil, &courses, http.StatusOK); err != nil {
+			return nil, err
+		}
+		return courses, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]Course(nil), value.([]Course)...), nil
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Enrollment names a set of users to enroll in, check against or revoke
+// from a batch.
+type Enrollment struct {
+	CourseID  int      `json:"course_id,omitempty"`
+	BatchID   int      `json:"batch_id"`
+	Usernames []string `json:"usernames"`
+}
+
+// EnrollResult reports which users of an EnrollUsers request were enrolled
+// and why the others were not.
+type EnrollResult struct {
+	Enrolled []string        `json:"enrolled"`
+	Failed   []EnrollFailure `json:"failed"`
+}
+
+// EnrollFailure is a user that could not be enrolled.
+type EnrollFailure struct {
+	Username string `json:"username"`
+	Reason   string `json:"reason"`
+}
+
+// EnrollUsers enrolls users in a batch. Enrolling a user twice has no
+// effect, so the request is retried on transient failures. When the API
+// does not report a result per user, every user counts as enrolled.
+func (c *Client) EnrollUsers(ctx context.Context, enrollment Enrollment) (*EnrollResult, error) {
+	var body []byte
+	err := c.do(retrySafe(ctx), "enroll users", "POST", c.APIURL+"/admin/enrollment/enroll_users/", enrollment, &body, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+
+	// The users are enrolled by now, so a body that is not a per-user
+	// result, such as a list or plain text, is not an error.
+	var result EnrollResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		result = EnrollResult{}
+	}
+
+	if result.Enrolled == nil && result.Failed == nil {
+		result.Enrolled = enrollment.Usernames
+	}
+
+	return &result, nil
+}
+
+// EnrollmentStatus tells whether a user is enrolled in a batch.
+type EnrollmentStatus struct {
+	Username   string `json:"username"`
+	IsEnrolled bool   `json:"is_enrolled"`
+}
+
+// CheckUsers returns the enrollment status of users in a batch. A response
+// that leaves out some of the users is an error rather than a sign that
+// they are not enrolled.
+func (c *Client) CheckUsers(ctx context.Context, enrollment Enrollment) ([]EnrollmentStatus, error) {
+	var statuses []EnrollmentStatus
+	err := c.do(retrySafe(ctx), "check user enrollment", "POST", c.APIURL+"/admin/enrollment/check_user/", enrollment, &statuses, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]struct{}, len(statuses))
+	for _, status := range statuses {
+		found[strings.ToLower(strings.TrimSpace(status.Username))] = struct{}{}
+	}
+
+	var missing []string
+	for _, username := range enrollment.Usernames {
+		if _, ok := found[strings.ToLower(strings.TrimSpace(username))]; !ok {
+			missing = append(missing, username)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("failed to check user enrollment: no status returned for %s", strings.Join(missing, ", "))
+	}
+
+	return statuses, nil
+}
+
+// RevokeUsers revokes the enrollment of users from a batch. Like
+// EnrollUsers it is retried on transient failures.
+func (c *Client) RevokeUsers(ctx context.Context, enrollment Enrollment) error {
+	return c.do(retrySafe(ctx), "revoke users", "POST", c.APIURL+"/admin/enrollment/revoke_users/", enrollment, nil, http.StatusOK)
+}
+
+// Enrollee is a user enrolled in a batch.
+type Enrollee struct {
+	Username   string  `json:"username"`
+	EnrolledAt string  `json:"enrolled_at"`
+	IsPass     bool    `json:"is_pass"`
+	Progress   float64 `json:"progress"`
+}
+
+// ListEnrollments returns the users enrolled in a batch.
+func (c *Client) ListEnrollments(ctx context.Context, batchID int) ([]Enrollee, error) {
+	url := fmt.Sprintf("%s/admin/enrollment/?batch_id=%d", c.APIURL, batchID)
+
+	var enrollees []Enrollee
+	if err := c.do(ctx, "get enrollments", "GET", url, nil, &enrollees, http.StatusOK); err != nil {
+		return nil, err
+	}
+
+	return enrollees, nil
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// maxErrorBody bounds how much of an error response is read.
+const maxErrorBody = 64 << 10
+
+// ErrNotFound is matched by errors.Is when a requested course, batch or
+// enrollment does not exist.
+var ErrNotFound = errors.New("not found")
+
+// Error is returned when the API answers with an unexpected status.
+type Error struct {
+	// Op describes the failed operation, for example "create class".
+	Op         string
+	StatusCode int
+	Status     string
+
+	// Detail is the message of the response body that is not about a
+	// single field, for example a "detail" or "non_field_errors" entry.
+	Detail string
+	// FieldErrors holds the validation messages of the response body by
+	// the name of the field they are about.
+	FieldErrors map[string][]string
+
+	retryAfter string
+}
+
+func newError(op string, resp *http.Response) *Error {
+	e := &Error{
+		Op:         op,
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		retryAfter: resp.Header.Get("Retry-After"),
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if err == nil {
+		e.parseBody(body, resp.Header.Get("Content-Type"))
+	}
+
+	return e
+}
+
+// parseBody reads a Django REST framework style error body: either an
+// object mapping field names to messages, with "detail" and
+// "non_field_errors" for the other messages, or a list of messages. Plain
+// text bodies are kept as the detail, HTML error pages are dropped.
+func (e *Error) parseBody(body []byte, contentType string) {
+	text := strings.TrimSpace(string(body))
+	if text == "" {
+		return
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		if !strings.Contains(contentType, "html") && !strings.HasPrefix(text, "<") {
+			if len(text) > 512 {
+				text = text[:512] + "..."
+			}
+			e.Detail = text
+		}
+		return
+	}
+
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		e.Detail = strings.Join(errorMessages(value), " ")
+		return
+	}
+
+	var details []string
+	for field, messages := range fields {
+		switch field {
+		case "detail", "message", "error", "non_field_errors":
+			details = append(details, errorMessages(messages)...)
+		default:
+			if e.FieldErrors == nil {
+				e.FieldErrors = make(map[string][]string)
+			}
+			e.FieldErrors[field] = errorMessages(messages)
+		}
+	}
+	sort.Strings(details)
+	e.Detail = strings.Join(details, " ")
+}
+
+// errorMessages flattens the messages of a field, which may be a string, a
+// list of strings or nested objects.
+func errorMessages(value interface{}) []string {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return []string{v}
+	case []interface{}:
+		var messages []string
+		for _, item := range v {
+			messages = append(messages, errorMessages(item)...)
+		}
+		return messages
+	case map[string]interface{}:
+		var messages []string
+		for _, key := range sortedKeys(v) {
+			for _, message := range errorMessages(v[key]) {
+				messages = append(messages, key+": "+message)
+			}
+		}
+		return messages
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("failed to %s, status: %s", e.Op, e.Status)
+
+	var details []string
+	if e.Detail != "" {
+		details = append(details, e.Detail)
+	}
+	for _, field := range e.Fields() {
+		details = append(details, field+": "+strings.Join(e.FieldErrors[field], " "))
+	}
+	if len(details) > 0 {
+		msg += ": " + strings.Join(details, "; ")
+	}
+
+	return msg
+}
+
+// Fields returns the names of the fields with validation messages, sorted.
+func (e *Error) Fields() []string {
+	fields := make([]string, 0, len(e.FieldErrors))
+	for field := range e.FieldErrors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// Is makes a 404 response match ErrNotFound.
+func (e *Error) Is(target error) bool {
+	return target == ErrNotFound && e.StatusCode == http.StatusNotFound
+}
+
+// IsStatus reports whether err is an *Error with the given status code.
+func IsStatus(err error, statusCode int) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == statusCode
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
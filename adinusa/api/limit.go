@@ -0,0 +1,115 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// limitTransport spaces requests out to a maximum rate and caps the number
+// of requests in flight. A single limitTransport is shared by every request
+// of a Client, so the limits hold across resources applied in parallel.
+type limitTransport struct {
+	base http.RoundTripper
+
+	// interval is the minimum time between the start of two requests, zero
+	// when the rate is not limited.
+	interval time.Duration
+	mu       sync.Mutex
+	next     time.Time
+
+	// slots holds one token per request in flight, nil when concurrency is
+	// not limited.
+	slots chan struct{}
+}
+
+func newLimitTransport(base http.RoundTripper, config Config) *limitTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	t := &limitTransport{base: base}
+
+	if config.RequestsPerSecond > 0 {
+		t.interval = time.Duration(float64(time.Second) / config.RequestsPerSecond)
+	}
+	if config.MaxConcurrentRequests > 0 {
+		t.slots = make(chan struct{}, config.MaxConcurrentRequests)
+	}
+
+	return t
+}
+
+func (t *limitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	if err := t.acquire(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := t.wait(ctx); err != nil {
+		t.release()
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		t.release()
+		return nil, err
+	}
+
+	// The request stays in flight until its response has been read.
+	resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: t.release}
+	return resp, nil
+}
+
+// wait blocks until the request may start according to the rate limit.
+func (t *limitTransport) wait(ctx context.Context) error {
+	if t.interval == 0 {
+		return nil
+	}
+
+	t.mu.Lock()
+	now := time.Now()
+	if t.next.Before(now) {
+		t.next = now
+	}
+	start := t.next
+	t.next = t.next.Add(t.interval)
+	t.mu.Unlock()
+
+	return sleep(ctx, time.Until(start))
+}
+
+func (t *limitTransport) acquire(ctx context.Context) error {
+	if t.slots == nil {
+		return nil
+	}
+
+	select {
+	case t.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *limitTransport) release() {
+	if t.slots != nil {
+		<-t.slots
+	}
+}
+
+type releaseOnClose struct {
+	io.ReadCloser
+	release func()
+	once    sync.Once
+}
+
+func (b *releaseOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
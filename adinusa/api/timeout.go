@@ -0,0 +1,57 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// timeoutTransport bounds every attempt of a request, from sending it until
+// its response body is closed. Waiting for a retry or for the rate limit
+// does not count against the timeout.
+type timeoutTransport struct {
+	base    http.RoundTripper
+	timeout time.Duration
+}
+
+func newTimeoutTransport(base http.RoundTripper, config Config) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if config.RequestTimeout <= 0 {
+		return base
+	}
+
+	return &timeoutTransport{base: base, timeout: config.RequestTimeout}
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		// A timed out attempt may be retried, a cancelled request may not.
+		if ctx.Err() == context.DeadlineExceeded && req.Context().Err() == nil {
+			return nil, &timeoutError{timeout: t.timeout}
+		}
+		return nil, err
+	}
+
+	resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: cancel}
+	return resp, nil
+}
+
+// timeoutError is returned when an attempt exceeds the request timeout. It
+// is a net.Error, so the attempt is retried like other connection failures.
+type timeoutError struct {
+	timeout time.Duration
+}
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("request timed out after %s", e.timeout)
+}
+
+func (e *timeoutError) Timeout() bool   { return true }
+func (e *timeoutError) Temporary() bool { return true }
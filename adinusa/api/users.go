@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// userLookupSize is the maximum number of usernames looked up in a single
+// request.
+const userLookupSize = 50
+
+// User is an Adinusa user account.
+type User struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+}
+
+// UsersExist reports for each username whether an account with that name
+// exists, ignoring case. Usernames are looked up in batches, and the result
+// is cached per username for the lifetime of the client.
+func (c *Client) UsersExist(ctx context.Context, usernames []string) (map[string]bool, error) {
+	result := make(map[string]bool, len(usernames))
+
+	var pending []string
+	for _, username := range usernames {
+		if exists, ok := c.cache.peek(userCacheKey(username)); ok {
+			result[username] = exists.(bool)
+			continue
+		}
+		pending = append(pending, username)
+	}
+
+	for start := 0; start < len(pending); start += userLookupSize {
+		chunk := pending[start:min(start+userLookupSize, len(pending))]
+
+		query := url.Values{"username__in": {strings.Join(chunk, ",")}}
+		var users []User
+		if err := c.do(ctx, "get users", "GET", fmt.Sprintf("%s/admin/users/?%s", c.APIURL, query.Encode()), nil, &users, http.StatusOK); err != nil {
+			return nil, err
+		}
+
+		found := make(map[string]struct{}, len(users))
+		for _, user := range users {
+			found[strings.ToLower(user.Username)] = struct{}{}
+		}
+
+		for _, username := range chunk {
+			_, exists := found[strings.ToLower(username)]
+			result[username] = exists
+			c.cache.put(userCacheKey(username), exists)
+		}
+	}
+
+	return result, nil
+}
+
+func userCacheKey(username string) string {
+	return "users/" + strings.ToLower(username)
+}
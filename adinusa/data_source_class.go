@@ -0,0 +1,109 @@
+package adinusa
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+
+	"terraform-provider-adinusa/adinusa/api"
+)
+
+func dataSourceClass() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceClassRead,
+
+		Schema: map[string]*schema.Schema{
+			"id": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.StringMatch(integerRegexp, "must be a class (batch) ID"),
+				ExactlyOneOf: []string{"id", "class_name"},
+			},
+			"class_name": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				RequiredWith: []string{"course_name"},
+			},
+			"course_name": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				RequiredWith: []string{"class_name"},
+			},
+			"course_id": {
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"start_date": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"end_date": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"group_type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"is_last_batch": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"is_enroll_pass": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"is_certificate": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"is_schedule": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"is_active": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceClassRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	client := m.(*api.Client)
+
+	classID, _ := strconv.Atoi(d.Get("id").(string))
+	if classID == 0 {
+		var err error
+		_, classID, err = resolveClass(ctx, client, d.Get("course_name").(string), d.Get("class_name").(string))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	batch, err := client.GetBatch(ctx, classID)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(strconv.Itoa(classID))
+	if err := setClassData(d, batch); err != nil {
+		return diag.FromErr(err)
+	}
+
+	courseID, err := getBatchCourseID(ctx, client, batch)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.Set("course_id", courseID)
+
+	return diags
+}
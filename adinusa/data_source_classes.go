@@ -0,0 +1,187 @@
+package adinusa
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"terraform-provider-adinusa/adinusa/api"
+)
+
+func dataSourceClasses() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceClassesRead,
+
+		Schema: map[string]*schema.Schema{
+			"course_name": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"course_name", "course_id"},
+			},
+			"course_id": {
+				Type:     schema.TypeInt,
+				Optional: true,
+			},
+			"is_active": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"start_date": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateDate,
+			},
+			"end_date": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validateDate,
+			},
+			"name_prefix": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"classes": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"class_name": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"start_date": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"end_date": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"group_type": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"is_last_batch": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"is_enroll_pass": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"is_certificate": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"is_schedule": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"is_active": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceClassesRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	client := m.(*api.Client)
+	namePrefix := d.Get("name_prefix").(string)
+
+	// is_active filters only when it is set, false included.
+	filterActive := !d.GetRawConfig().GetAttr("is_active").IsNull()
+	isActive := d.Get("is_active").(bool)
+
+	var from, to time.Time
+	if v, ok := d.GetOk("start_date"); ok {
+		from, _ = parseDate(v.(string))
+	}
+	if v, ok := d.GetOk("end_date"); ok {
+		to, _ = parseDate(v.(string))
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return diag.Errorf("end_date must not be before start_date")
+	}
+
+	courseID := d.Get("course_id").(int)
+	if courseName, ok := d.GetOk("course_name"); ok {
+		var err error
+		courseID, err = getCourseIDByName(ctx, client, courseName.(string))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	batches, err := client.ListBatches(ctx, courseID)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	var ids []string
+	var classes []map[string]interface{}
+	for _, batch := range batches {
+		if !strings.HasPrefix(batch.Name, namePrefix) {
+			continue
+		}
+		if filterActive && batch.IsActive != isActive {
+			continue
+		}
+
+		// Keep the classes that run at some point between start_date and
+		// end_date.
+		if !from.IsZero() {
+			end, err := parseDate(batch.EndDate)
+			if err != nil || end.Before(from) {
+				continue
+			}
+		}
+		if !to.IsZero() {
+			start, err := parseDate(batch.StartDate)
+			if err != nil || start.After(to) {
+				continue
+			}
+		}
+
+		groupTypeStr, err := convertGroupTypeToString(batch.GroupType)
+		if err != nil {
+			return diag.FromErr(fmt.Errorf("class %d: %v", batch.ID, err))
+		}
+
+		ids = append(ids, strconv.Itoa(batch.ID))
+		classes = append(classes, map[string]interface{}{
+			"id":             batch.ID,
+			"class_name":     batch.Name,
+			"start_date":     normalizeDate(batch.StartDate),
+			"end_date":       normalizeDate(batch.EndDate),
+			"group_type":     groupTypeStr,
+			"is_last_batch":  batch.IsLastBatch,
+			"is_enroll_pass": batch.IsEnrollPass,
+			"is_certificate": batch.IsCertificate,
+			"is_schedule":    batch.IsSchedule,
+			"is_active":      batch.IsActive,
+		})
+	}
+
+	if err := d.Set("classes", classes); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(fmt.Sprintf("%d/%d", courseID, schema.HashString(strings.Join(ids, ","))))
+
+	return diags
+}
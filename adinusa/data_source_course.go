@@ -0,0 +1,89 @@
+package adinusa
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+
+	"terraform-provider-adinusa/adinusa/api"
+)
+
+func dataSourceCourse() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceCourseRead,
+
+		Schema: map[string]*schema.Schema{
+			"id": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.StringMatch(integerRegexp, "must be a course ID"),
+				ExactlyOneOf: []string{"id", "title"},
+			},
+			"title": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{"id", "title"},
+			},
+			"slug": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"description": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"category": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"is_active": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceCourseRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	client := m.(*api.Client)
+	id := d.Get("id").(string)
+	title := d.Get("title").(string)
+
+	courses, err := client.ListCourses(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	var course *api.Course
+	for i := range courses {
+		if strconv.Itoa(courses[i].ID) == id || (id == "" && courses[i].Title == title) {
+			course = &courses[i]
+			break
+		}
+	}
+
+	if course == nil {
+		if id != "" {
+			return diag.Errorf("course with ID %s not found", id)
+		}
+		return diag.Errorf("course '%s' not found", title)
+	}
+
+	d.SetId(strconv.Itoa(course.ID))
+	d.Set("title", course.Title)
+	d.Set("slug", course.Slug)
+	d.Set("description", course.Description)
+	d.Set("is_active", course.Active())
+	if course.Category != nil {
+		d.Set("category", course.Category.Name)
+	}
+
+	return diags
+}
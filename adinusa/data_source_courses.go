@@ -0,0 +1,130 @@
+package adinusa
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+
+	"terraform-provider-adinusa/adinusa/api"
+)
+
+func dataSourceCourses() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceCoursesRead,
+
+		Schema: map[string]*schema.Schema{
+			"title_regex": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.StringIsValidRegExp,
+			},
+			"category": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"is_active": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"courses": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"id": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"title": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"slug": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"category": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"is_active": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceCoursesRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	client := m.(*api.Client)
+
+	var titleRegex *regexp.Regexp
+	if v, ok := d.GetOk("title_regex"); ok {
+		titleRegex = regexp.MustCompile(v.(string))
+	}
+	category := d.Get("category").(string)
+
+	// is_active filters only when it is set, false included.
+	filterActive := !d.GetRawConfig().GetAttr("is_active").IsNull()
+	isActive := d.Get("is_active").(bool)
+
+	courses, err := client.ListCourses(ctx)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	var ids []string
+	var result []map[string]interface{}
+	for _, course := range courses {
+		if titleRegex != nil && !titleRegex.MatchString(course.Title) {
+			continue
+		}
+		if category != "" && !courseInCategory(course, category) {
+			continue
+		}
+		if filterActive && course.Active() != isActive {
+			continue
+		}
+
+		categoryName := ""
+		if course.Category != nil {
+			categoryName = course.Category.Name
+		}
+
+		ids = append(ids, strconv.Itoa(course.ID))
+		result = append(result, map[string]interface{}{
+			"id":        course.ID,
+			"title":     course.Title,
+			"slug":      course.Slug,
+			"category":  categoryName,
+			"is_active": course.Active(),
+		})
+	}
+
+	if err := d.Set("courses", result); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(strconv.Itoa(schema.HashString(strings.Join(ids, ","))))
+
+	return diags
+}
+
+// courseInCategory matches a category by name, ignoring case, or by ID.
+func courseInCategory(course api.Course, category string) bool {
+	if course.Category == nil {
+		return false
+	}
+
+	return strings.EqualFold(course.Category.Name, category) || strconv.Itoa(course.Category.ID) == category
+}
@@ -0,0 +1,94 @@
+package adinusa
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"terraform-provider-adinusa/adinusa/api"
+)
+
+func dataSourceEnrollments() *schema.Resource {
+	return &schema.Resource{
+		ReadContext: dataSourceEnrollmentsRead,
+
+		Schema: map[string]*schema.Schema{
+			"course_name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"class_name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"usernames": {
+				Type:     schema.TypeList,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Computed: true,
+			},
+			"enrollments": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"username": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"enrolled_at": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"is_pass": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"progress": {
+							Type:     schema.TypeFloat,
+							Computed: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func dataSourceEnrollmentsRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	client := m.(*api.Client)
+	courseName := d.Get("course_name").(string)
+	className := d.Get("class_name").(string)
+
+	courseID, batchID, err := resolveClass(ctx, client, courseName, className)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	enrollees, err := client.ListEnrollments(ctx, batchID)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	usernames := make([]string, 0, len(enrollees))
+	enrollments := make([]map[string]interface{}, 0, len(enrollees))
+	for _, enrollee := range enrollees {
+		usernames = append(usernames, enrollee.Username)
+		enrollments = append(enrollments, map[string]interface{}{
+			"username":    enrollee.Username,
+			"enrolled_at": enrollee.EnrolledAt,
+			"is_pass":     enrollee.IsPass,
+			"progress":    enrollee.Progress,
+		})
+	}
+
+	d.SetId(enrollmentID(courseID, batchID))
+	d.Set("usernames", usernames)
+	if err := d.Set("enrollments", enrollments); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return diags
+}
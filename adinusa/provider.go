@@ -2,20 +2,39 @@ package adinusa
 
 import (
 	"context"
-	"encoding/json"
-	"net/http"
-	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
 
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+
+	"terraform-provider-adinusa/adinusa/api"
 )
 
-type Client struct {
-	APIURL    string
-	AuthToken string
-	*http.Client
-}
+// integerRegexp matches the numeric IDs used by the Adinusa API.
+var integerRegexp = regexp.MustCompile(`^[0-9]+$`)
+
+// dateLayout is the YYYY-MM-DD format of the dates used by the Adinusa API.
+const dateLayout = "2006-01-02"
+
+// dateTimeLayouts are the formats of timestamps the API may return instead
+// of a plain date.
+var dateTimeLayouts = []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05"}
+
+// dateZone is the zone in which class dates are expressed: Western
+// Indonesia Time, UTC+7. Timestamps are converted to it before their date
+// is taken, and timestamps without an offset are read in it.
+var dateZone = time.FixedZone("WIB", 7*60*60)
 
 func Provider() *schema.Provider {
 	return &schema.Provider{
@@ -44,10 +63,110 @@ func Provider() *schema.Provider {
 				DefaultFunc: schema.EnvDefaultFunc("ADINUSA_PASSWORD", nil),
 				Description: "Password for Adinusa API",
 			},
+			"request_timeout": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      60,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Number of seconds after which a single request to the Adinusa API is aborted, 0 for no timeout",
+			},
+			"max_retries": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      3,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Maximum number of retries for requests that fail with a transient error",
+			},
+			"retry_min_wait": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      1,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Minimum number of seconds to wait before retrying a request",
+			},
+			"retry_max_wait": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      30,
+				ValidateFunc: validation.IntAtLeast(1),
+				Description:  "Maximum number of seconds to wait before retrying a request",
+			},
+			"requests_per_second": {
+				Type:         schema.TypeFloat,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.FloatAtLeast(0),
+				Description:  "Maximum number of requests per second sent to the Adinusa API, 0 for no limit",
+			},
+			"max_concurrent_requests": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      0,
+				ValidateFunc: validation.IntAtLeast(0),
+				Description:  "Maximum number of requests to the Adinusa API in flight at the same time, 0 for no limit",
+			},
+			"ca_cert_file": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				DefaultFunc:   schema.EnvDefaultFunc("ADINUSA_CA_CERT_FILE", nil),
+				ConflictsWith: []string{"ca_cert_pem"},
+				Description:   "Path to a PEM file of CA certificates trusted in addition to the system roots",
+			},
+			"ca_cert_pem": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"ca_cert_file"},
+				Description:   "PEM encoded CA certificates trusted in addition to the system roots",
+			},
+			"client_cert_file": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"client_cert_pem"},
+				Description:   "Path to a PEM file of the client certificate used for mutual TLS",
+			},
+			"client_cert_pem": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"client_cert_file"},
+				Description:   "PEM encoded client certificate used for mutual TLS",
+			},
+			"client_key_file": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ConflictsWith: []string{"client_key_pem"},
+				Description:   "Path to a PEM file of the private key of the client certificate",
+			},
+			"client_key_pem": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Sensitive:     true,
+				ConflictsWith: []string{"client_key_file"},
+				Description:   "PEM encoded private key of the client certificate",
+			},
+			"insecure_skip_verify": {
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				Description: "Skip the verification of the server certificate. Only meant for testing",
+			},
+			"proxy_url": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ValidateFunc: validation.IsURLWithScheme([]string{"http", "https", "socks5"}),
+				Description:  "URL of the proxy used for requests to Adinusa. The HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are used when not set",
+			},
 		},
 		ResourcesMap: map[string]*schema.Resource{
-			"adinusa_enroll_user": resourceEnrollUser(),
-			"adinusa_class": resourceClass(),
+			"adinusa_enroll_user":      resourceEnrollUser(),
+			"adinusa_class":            resourceClass(),
+			"adinusa_class_enrollment": resourceClassEnrollment(),
+		},
+		DataSourcesMap: map[string]*schema.Resource{
+			"adinusa_class":       dataSourceClass(),
+			"adinusa_classes":     dataSourceClasses(),
+			"adinusa_course":      dataSourceCourse(),
+			"adinusa_courses":     dataSourceCourses(),
+			"adinusa_enrollments": dataSourceEnrollments(),
 		},
 		ConfigureContextFunc: providerConfigure,
 	}
@@ -56,116 +175,294 @@ func Provider() *schema.Provider {
 func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
 	var diags diag.Diagnostics
 
-	mainAPIURL := d.Get("main_api_url").(string)
-	apiURL := d.Get("api_url").(string)
-	username := d.Get("username").(string)
-	password := d.Get("password").(string)
+	transport, err := providerTransport(d)
+	if err != nil {
+		return nil, diag.FromErr(err)
+	}
 
-	loginURL := mainAPIURL + "/auth/login"
-	payload := map[string]string{
-		"username": username,
-		"password": password,
+	if d.Get("insecure_skip_verify").(bool) {
+		diags = append(diags, diag.Diagnostic{
+			Severity:      diag.Warning,
+			Summary:       "TLS certificate verification is disabled",
+			Detail:        "insecure_skip_verify is set, so the identity of the Adinusa API is not checked and the credentials may be sent to anyone in between. Use ca_cert_file or ca_cert_pem to trust a private CA instead.",
+			AttributePath: cty.GetAttrPath("insecure_skip_verify"),
+		})
 	}
 
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return nil, diag.FromErr(err)
+	client := api.NewClient(api.Config{
+		MainAPIURL: d.Get("main_api_url").(string),
+		APIURL:     d.Get("api_url").(string),
+		Username:   d.Get("username").(string),
+		Password:   d.Get("password").(string),
+		HTTPClient: &http.Client{Transport: transport},
+
+		RequestTimeout: time.Duration(d.Get("request_timeout").(int)) * time.Second,
+
+		MaxRetries:   d.Get("max_retries").(int),
+		RetryMinWait: time.Duration(d.Get("retry_min_wait").(int)) * time.Second,
+		RetryMaxWait: time.Duration(d.Get("retry_max_wait").(int)) * time.Second,
+
+		RequestsPerSecond:     d.Get("requests_per_second").(float64),
+		MaxConcurrentRequests: d.Get("max_concurrent_requests").(int),
+	})
+
+	if err := client.Login(ctx); err != nil {
+		return nil, append(diags, apiDiagnostics(err, loginAttributes)...)
 	}
 
-	req, err := http.NewRequest("POST", loginURL, bytes.NewBuffer(body))
+	return client, diags
+}
+
+func getCourseIDByName(ctx context.Context, client *api.Client, courseName string) (int, error) {
+	courses, err := client.ListCourses(ctx)
 	if err != nil {
-		return nil, diag.FromErr(err)
+		return 0, err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	for _, course := range courses {
+		if course.Title == courseName {
+			return course.ID, nil
+		}
+	}
+
+	return 0, fmt.Errorf("course '%s' %w", courseName, api.ErrNotFound)
+}
+
+func getBatchIDByClass(ctx context.Context, client *api.Client, courseID int, className string, courseName string) (int, error) {
+	batches, err := client.ListBatches(ctx, courseID)
 	if err != nil {
-		return nil, diag.FromErr(err)
+		return 0, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, diag.Errorf("failed to authenticate, status: %s", resp.Status)
+	for _, batch := range batches {
+		if batch.Name == className {
+			return batch.ID, nil
+		}
 	}
 
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, diag.FromErr(err)
+	return 0, fmt.Errorf("batch '%s' %w for course '%s'", className, api.ErrNotFound, courseName)
+}
+
+// resolveClass looks up the course and batch IDs of a class by name.
+func resolveClass(ctx context.Context, client *api.Client, courseName string, className string) (int, int, error) {
+	// Get Course ID
+	courseID, err := getCourseIDByName(ctx, client, courseName)
+	if err != nil {
+		return 0, 0, err
 	}
 
-	token, ok := result["access"].(string)
-	if !ok {
-		return nil, diag.Errorf("failed to get token")
+	// Get Batch ID
+	batchID, err := getBatchIDByClass(ctx, client, courseID, className, courseName)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return courseID, batchID, nil
+}
+
+// getBatchCourseID returns the ID of the course of a batch, looking the
+// course up by title when the API response does not include its ID.
+func getBatchCourseID(ctx context.Context, client *api.Client, batch *api.Batch) (int, error) {
+	if batch.CourseID != 0 {
+		return batch.CourseID, nil
+	}
+	if batch.Course.ID != 0 {
+		return batch.Course.ID, nil
 	}
 
-	return &Client{
-		APIURL:    apiURL,
-		AuthToken: token,
-		Client:    client,
-	}, diags
+	return getCourseIDByName(ctx, client, batch.Course.Title)
 }
 
-func getCourseIDByName(client *Client, courseName string) (int, error) {
-	coursesURL := client.APIURL + "/courses/"
-	req, err := http.NewRequest("GET", coursesURL, nil)
+// providerTransport builds the HTTP transport from the TLS and proxy
+// settings of the provider.
+func providerTransport(d *schema.ResourceData) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: d.Get("insecure_skip_verify").(bool),
+	}
+
+	// Trust the configured CA on top of the system roots
+	caPEM, err := readPEM(d, "ca_cert_file", "ca_cert_pem")
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	if caPEM != nil {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, errors.New("no valid certificate found in the CA certificates")
+		}
+		tlsConfig.RootCAs = pool
 	}
 
-	req.Header.Set("Authorization", "Bearer "+client.AuthToken)
-	resp, err := client.Do(req)
+	// Client certificate for mutual TLS
+	certPEM, err := readPEM(d, "client_cert_file", "client_cert_pem")
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+	keyPEM, err := readPEM(d, "client_key_file", "client_key_pem")
+	if err != nil {
+		return nil, err
+	}
+	if (certPEM == nil) != (keyPEM == nil) {
+		return nil, errors.New("a client certificate and its key must be set together")
 	}
-	defer resp.Body.Close()
+	if certPEM != nil {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport.TLSClientConfig = tlsConfig
 
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("failed to get courses, status: %s", resp.Status)
+	if proxyURL := d.Get("proxy_url").(string); proxyURL != "" {
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy_url: %v", err)
+		}
+		transport.Proxy = http.ProxyURL(u)
 	}
 
-	var courses []map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&courses); err != nil {
-		return 0, err
+	return transport, nil
+}
+
+// readPEM returns the PEM data given either as a file path in fileKey or
+// inline in pemKey, or nil when neither is set. ConflictsWith does not see
+// values taken from the environment, so both are checked here.
+func readPEM(d *schema.ResourceData, fileKey, pemKey string) ([]byte, error) {
+	path, data := d.Get(fileKey).(string), d.Get(pemKey).(string)
+	if path != "" && data != "" {
+		return nil, fmt.Errorf("only one of %s and %s can be set", fileKey, pemKey)
 	}
 
-	for _, course := range courses {
-		if course["title"].(string) == courseName {
-			return int(course["id"].(float64)), nil
+	if path != "" {
+		contents, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", fileKey, err)
 		}
+		return contents, nil
+	}
+
+	if data != "" {
+		return []byte(data), nil
 	}
 
-	return 0, fmt.Errorf("course '%s' not found", courseName)
+	return nil, nil
 }
 
-func getBatchIDByClass(client *Client, courseID int, className string, courseName string) (int, error) {
-	batchesURL := fmt.Sprintf("%s/admin/batchs/?course_id=%d", client.APIURL, courseID)
-	req, err := http.NewRequest("GET", batchesURL, nil)
-	if err != nil {
-		return 0, err
+// rawConfigReader is implemented by both schema.ResourceData and
+// schema.ResourceDiff.
+type rawConfigReader interface {
+	GetRawConfig() cty.Value
+}
+
+// isConfigured reports whether an argument is set in the configuration,
+// possibly to a value that is only known after apply. It is false when no
+// configuration is available, as during a refresh.
+func isConfigured(d rawConfigReader, key string) bool {
+	config := d.GetRawConfig()
+	return config.IsKnown() && !config.IsNull() && !config.GetAttr(key).IsNull()
+}
+
+// loginAttributes maps the fields of a login request to provider arguments.
+var loginAttributes = map[string]string{
+	"username": "username",
+	"password": "password",
+}
+
+// apiDiagnostics turns an error into diagnostics. Each field of an API
+// validation error becomes its own diagnostic, attached to the attribute
+// the field maps to in attributes. Other errors are returned as is.
+func apiDiagnostics(err error, attributes map[string]string) diag.Diagnostics {
+	var apiErr *api.Error
+	if !errors.As(err, &apiErr) {
+		return diag.FromErr(err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+client.AuthToken)
-	resp, err := client.Do(req)
-	if err != nil {
-		return 0, err
+	summary := fmt.Sprintf("Failed to %s (%s)", apiErr.Op, apiErr.Status)
+
+	var diags diag.Diagnostics
+	if apiErr.Detail != "" || len(apiErr.FieldErrors) == 0 {
+		detail := apiErr.Detail
+		if detail == "" {
+			detail = "Adinusa did not say why the request failed."
+		}
+		diags = append(diags, diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  summary,
+			Detail:   detail,
+		})
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("failed to get batches, status: %s", resp.Status)
+	for _, field := range apiErr.Fields() {
+		fieldDiag := diag.Diagnostic{
+			Severity: diag.Error,
+			Summary:  summary,
+			Detail:   strings.Join(apiErr.FieldErrors[field], " "),
+		}
+		if attribute, ok := attributes[field]; ok {
+			fieldDiag.AttributePath = cty.GetAttrPath(attribute)
+		} else {
+			fieldDiag.Detail = field + ": " + fieldDiag.Detail
+		}
+		diags = append(diags, fieldDiag)
 	}
 
-	var batches []map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&batches); err != nil {
-		return 0, err
+	return diags
+}
+
+// parseDate parses a YYYY-MM-DD date, or a timestamp returned by the API,
+// which is reduced to its date in dateZone. The result is midnight UTC of
+// that date, like a plain date parsed with dateLayout.
+func parseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+
+	date, err := time.Parse(dateLayout, value)
+	if err == nil {
+		return date, nil
 	}
 
-	for _, batch := range batches {
-		if batch["batch"].(string) == className {
-			return int(batch["id"].(float64)), nil
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, dateZone); err == nil {
+			year, month, day := t.In(dateZone).Date()
+			return time.Date(year, month, day, 0, 0, 0, 0, time.UTC), nil
 		}
 	}
 
-	return 0, fmt.Errorf("batch '%s' not found for course '%s'", className, courseName)
-}
\ No newline at end of file
+	return time.Time{}, err
+}
+
+// normalizeDate returns a date in YYYY-MM-DD format, so dates returned by
+// the API with a time or timezone suffix compare equal to the configured
+// ones. Values that are not dates are returned unchanged.
+func normalizeDate(value string) string {
+	date, err := parseDate(value)
+	if err != nil {
+		return value
+	}
+
+	return date.Format(dateLayout)
+}
+
+func suppressEquivalentDate(k, old, new string, d *schema.ResourceData) bool {
+	return normalizeDate(old) == normalizeDate(new)
+}
+
+func validateDate(v interface{}, k string) (ws []string, es []error) {
+	value, ok := v.(string)
+	if !ok {
+		return nil, []error{fmt.Errorf("expected type of %s to be string", k)}
+	}
+
+	if _, err := time.Parse(dateLayout, value); err != nil {
+		es = append(es, fmt.Errorf("%s must be a date in YYYY-MM-DD format, got %q", k, value))
+	}
+
+	return ws, es
+}
@@ -0,0 +1,50 @@
+package adinusa
+
+import "testing"
+
+func TestNormalizeDate(t *testing.T) {
+	cases := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "date", value: "2024-03-01", want: "2024-03-01"},
+		{name: "date with whitespace", value: " 2024-03-01 ", want: "2024-03-01"},
+		{name: "datetime", value: "2024-03-01T10:00:00", want: "2024-03-01"},
+		{name: "datetime with space", value: "2024-03-01 23:59:59", want: "2024-03-01"},
+		{name: "datetime with fraction", value: "2024-03-01T10:00:00.123456", want: "2024-03-01"},
+		{name: "local offset", value: "2024-03-01T00:00:00+07:00", want: "2024-03-01"},
+		{name: "UTC midnight of the local day", value: "2024-02-29T17:00:00Z", want: "2024-03-01"},
+		{name: "UTC with fraction", value: "2024-02-29T17:00:00.000Z", want: "2024-03-01"},
+		{name: "UTC evening is the next local day", value: "2024-03-01T20:00:00Z", want: "2024-03-02"},
+		{name: "other offset", value: "2024-03-01T00:30:00+08:00", want: "2024-02-29"},
+		{name: "invalid date", value: "2024-13-01", want: "2024-13-01"},
+		{name: "not a date", value: "soon", want: "soon"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := normalizeDate(tc.value); got != tc.want {
+				t.Errorf("normalizeDate(%q) = %q, want %q", tc.value, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseDateMatchesPlainDates(t *testing.T) {
+	plain, err := parseDate("2024-03-01")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stamp, err := parseDate("2024-02-29T17:00:00Z")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Dates compare equal whatever form they were returned in, so the
+	// start_date and end_date filters of adinusa_classes keep working.
+	if !stamp.Equal(plain) {
+		t.Errorf("parseDate(timestamp) = %v, want %v", stamp, plain)
+	}
+}
@@ -1,16 +1,20 @@
 package adinusa
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
+	"log"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+
+	"terraform-provider-adinusa/adinusa/api"
 )
 
 func resourceClass() *schema.Resource {
@@ -19,6 +23,16 @@ func resourceClass() *schema.Resource {
 		ReadContext:   resourceClassRead,
 		UpdateContext: resourceClassUpdate,
 		DeleteContext: resourceClassDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceClassImport,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
 
 		Schema: map[string]*schema.Schema{
 			"class_name": {
@@ -26,20 +40,32 @@ func resourceClass() *schema.Resource {
 				Required: true,
 			},
 			"course_name": {
-				Type:     schema.TypeString,
-				Required: true,
+				Type:         schema.TypeString,
+				Optional:     true,
+				Computed:     true,
+				ExactlyOneOf: []string{"course_name", "course_id"},
+			},
+			"course_id": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IntAtLeast(1),
 			},
 			"start_date": {
-				Type:     schema.TypeString,
-				Required: true,
+				Type:             schema.TypeString,
+				Required:         true,
+				ValidateDiagFunc: validation.ToDiagFunc(validateDate),
+				DiffSuppressFunc: suppressEquivalentDate,
 			},
 			"end_date": {
-				Type:     schema.TypeString,
-				Required: true,
+				Type:             schema.TypeString,
+				Required:         true,
+				ValidateDiagFunc: validation.ToDiagFunc(validateDate),
+				DiffSuppressFunc: suppressEquivalentDate,
 			},
 			"group_type": {
-				Type:     schema.TypeString,
-				Required: true,
+				Type:         schema.TypeString,
+				Required:     true,
 				ValidateFunc: validation.StringInSlice([]string{"internal", "eksternal"}, false),
 			},
 			"is_last_batch": {
@@ -67,206 +93,151 @@ func resourceClass() *schema.Resource {
 				Optional: true,
 				Default:  false,
 			},
+			"warn_past_start_date": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
 		},
+		CustomizeDiff: resourceClassCustomizeDiff,
 	}
 }
 
-func resourceClassCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	var diags diag.Diagnostics
-
-	client := m.(*Client)
-	courseName := d.Get("course_name").(string)
-	className := d.Get("class_name").(string)
-	startDate := d.Get("start_date").(string)
-	endDate := d.Get("end_date").(string)
-	groupTypeStr := d.Get("group_type").(string)
-	isLastBatch := d.Get("is_last_batch").(bool)
-	isEnrollPass := d.Get("is_enroll_pass").(bool)
-	isCertificate := d.Get("is_certificate").(bool)
-	isSchedule := d.Get("is_schedule").(bool)
-
-	groupType, err := convertGroupTypeToNumber(groupTypeStr)
-	if err != nil {
-		return diag.FromErr(err)
+// resourceClassCustomizeDiff checks that the class does not end before it
+// starts, and recomputes the course attribute that is not configured when
+// the configured one changes.
+func resourceClassCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if d.NewValueKnown("start_date") && d.NewValueKnown("end_date") {
+		start, startErr := parseDate(d.Get("start_date").(string))
+		end, endErr := parseDate(d.Get("end_date").(string))
+		if startErr == nil && endErr == nil && end.Before(start) {
+			return fmt.Errorf("end_date (%s) must not be before start_date (%s)", end.Format(dateLayout), start.Format(dateLayout))
+		}
 	}
 
-	// Get Course ID
-	courseID, err := getCourseIDByName(client, courseName)
-	if err != nil {
-		return diag.FromErr(err)
+	if d.Id() == "" {
+		return nil
 	}
 
-	// Create Class
-	createURL := client.APIURL + "/admin/batchs/"
-	payload := map[string]interface{}{
-		"batch":          className,
-		"start_date":     startDate,
-		"end_date":       endDate,
-		"group_type":     groupType,
-		"is_last_batch":  isLastBatch,
-		"is_enroll_pass": isEnrollPass,
-		"is_certificate": isCertificate,
-		"is_schedule":    isSchedule,
-		"course":         courseID,
+	if d.HasChange("course_name") && !isConfigured(d, "course_id") {
+		return d.SetNewComputed("course_id")
 	}
-
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return diag.FromErr(err)
+	if d.HasChange("course_id") && !isConfigured(d, "course_name") {
+		return d.SetNewComputed("course_name")
 	}
 
-	req, err := http.NewRequest("POST", createURL, bytes.NewBuffer(body))
-	if err != nil {
-		return diag.FromErr(err)
-	}
+	return nil
+}
+
+func resourceClassCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+client.AuthToken)
+	client := m.(*api.Client)
 
-	resp, err := client.Do(req)
+	// Get Course ID
+	courseID, err := classCourseID(ctx, client, d)
 	if err != nil {
-		return diag.FromErr(err)
+		return apiDiagnostics(err, classAttributes(d))
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusCreated {
-		return diag.Errorf("failed to create class, status: %s", resp.Status)
+	input, err := expandClass(d, courseID)
+	if err != nil {
+		return apiDiagnostics(err, classAttributes(d))
 	}
 
-	var result map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return diag.FromErr(err)
+	// Create Class
+	batch, err := client.CreateBatch(ctx, input)
+	if err != nil {
+		return apiDiagnostics(err, classAttributes(d))
 	}
 
 	// Set ID
-	classID := int(result["id"].(float64))
-	d.SetId(fmt.Sprintf("%d", classID))
+	d.SetId(strconv.Itoa(batch.ID))
+
+	// Warn about classes that have already started
+	if d.Get("warn_past_start_date").(bool) {
+		start, err := parseDate(d.Get("start_date").(string))
+		today, _ := parseDate(time.Now().Format(dateLayout))
+		if err == nil && start.Before(today) {
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Warning,
+				Summary:       "Class starts in the past",
+				Detail:        fmt.Sprintf("Class %q was created with start_date %s, which is before today.", d.Get("class_name").(string), start.Format(dateLayout)),
+				AttributePath: cty.GetAttrPath("start_date"),
+			})
+		}
+	}
 
 	// Activate Class if needed
 	if d.Get("is_active").(bool) {
-		err := changeClassStatus(client, classID, true)
-		if err != nil {
-			return diag.FromErr(err)
+		if err := client.ChangeBatchStatus(ctx, batch.ID, true); err != nil {
+			return apiDiagnostics(err, classAttributes(d))
 		}
 	}
 
-	return diags
+	// Fill in the course attribute that was not configured
+	return append(diags, resourceClassRead(ctx, d, m)...)
 }
 
 func resourceClassRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	client := m.(*Client)
-	classID := d.Id()
+	client := m.(*api.Client)
 
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s/admin/batchs/%s/", client.APIURL, classID), nil)
+	classID, err := strconv.Atoi(d.Id())
 	if err != nil {
-		return diag.FromErr(err)
+		return apiDiagnostics(err, classAttributes(d))
 	}
 
-	req.Header.Set("Authorization", "Bearer "+client.AuthToken)
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return diag.FromErr(err)
+	batch, err := client.GetBatch(ctx, classID)
+	if errors.Is(err, api.ErrNotFound) {
+		log.Printf("[WARN] Class %s not found, removing from state", d.Id())
+		d.SetId("")
+		return nil
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return diag.Errorf("failed to read class, status: %s", resp.Status)
+	if err != nil {
+		return apiDiagnostics(err, classAttributes(d))
 	}
 
-	var classResponse map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&classResponse); err != nil {
-		return diag.FromErr(err)
+	if err := setClassData(d, batch); err != nil {
+		return apiDiagnostics(err, classAttributes(d))
 	}
 
-	groupTypeStr, err := convertGroupTypeToString(int(classResponse["group_type"].(float64)))
+	courseID, err := getBatchCourseID(ctx, client, batch)
 	if err != nil {
-		return diag.FromErr(err)
+		return apiDiagnostics(err, classAttributes(d))
 	}
-
-	d.Set("class_name", classResponse["batch"].(string))
-	d.Set("course_name", classResponse["course_data"].(map[string]interface{})["title"].(string))
-	d.Set("start_date", classResponse["start_date"].(string))
-	d.Set("end_date", classResponse["end_date"].(string))
-	d.Set("group_type", groupTypeStr)
-	d.Set("is_last_batch", classResponse["is_last_batch"].(bool))
-	d.Set("is_enroll_pass", classResponse["is_enroll_pass"].(bool))
-	d.Set("is_certificate", classResponse["is_certificate"].(bool))
-	d.Set("is_schedule", classResponse["is_schedule"].(bool))
-	d.Set("is_active", classResponse["is_active"].(bool))
+	d.Set("course_id", courseID)
 
 	return nil
 }
 
 func resourceClassUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	client := m.(*Client)
-	classID := d.Id()
-
-	if d.HasChanges("class_name", "start_date", "end_date", "group_type", "is_last_batch", "is_enroll_pass", "is_certificate", "is_schedule", "course_name") {
-		className := d.Get("class_name").(string)
-		startDate := d.Get("start_date").(string)
-		endDate := d.Get("end_date").(string)
-		groupTypeStr := d.Get("group_type").(string)
-		isLastBatch := d.Get("is_last_batch").(bool)
-		isEnrollPass := d.Get("is_enroll_pass").(bool)
-		isCertificate := d.Get("is_certificate").(bool)
-		isSchedule := d.Get("is_schedule").(bool)
-		courseName := d.Get("course_name").(string)
-
-		groupType, err := convertGroupTypeToNumber(groupTypeStr)
-		if err != nil {
-			return diag.FromErr(err)
-		}
-
-		courseID, err := getCourseIDByName(client, courseName)
-		if err != nil {
-			return diag.FromErr(err)
-		}
-
-		classPayload := map[string]interface{}{
-			"batch":          className,
-			"start_date":     startDate,
-			"end_date":       endDate,
-			"group_type":     groupType,
-			"is_last_batch":  isLastBatch,
-			"is_enroll_pass": isEnrollPass,
-			"is_certificate": isCertificate,
-			"is_schedule":    isSchedule,
-			"course":         courseID,
-		}
+	client := m.(*api.Client)
 
-		body, err := json.Marshal(classPayload)
-		if err != nil {
-			return diag.FromErr(err)
-		}
+	classID, err := strconv.Atoi(d.Id())
+	if err != nil {
+		return apiDiagnostics(err, classAttributes(d))
+	}
 
-		req, err := http.NewRequest("PUT", fmt.Sprintf("%s/admin/batchs/%s/", client.APIURL, classID), bytes.NewBuffer(body))
+	if d.HasChanges("class_name", "start_date", "end_date", "group_type", "is_last_batch", "is_enroll_pass", "is_certificate", "is_schedule", "course_name", "course_id") {
+		courseID, err := classCourseID(ctx, client, d)
 		if err != nil {
-			return diag.FromErr(err)
+			return apiDiagnostics(err, classAttributes(d))
 		}
 
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Authorization", "Bearer "+client.AuthToken)
-
-		resp, err := client.Do(req)
+		input, err := expandClass(d, courseID)
 		if err != nil {
-			return diag.FromErr(err)
+			return apiDiagnostics(err, classAttributes(d))
 		}
-		defer resp.Body.Close()
 
-		if resp.StatusCode != http.StatusOK {
-			return diag.Errorf("failed to update class, status: %s", resp.Status)
+		if err := client.UpdateBatch(ctx, classID, input); err != nil {
+			return apiDiagnostics(err, classAttributes(d))
 		}
 	}
 
 	if d.HasChange("is_active") {
 		isActive := d.Get("is_active").(bool)
-		classIDInt, err := strconv.Atoi(classID)
-		if err != nil {
-			return diag.FromErr(err)
-		}
-		if err := changeClassStatus(client, classIDInt, isActive); err != nil {
-			return diag.FromErr(err)
+		if err := client.ChangeBatchStatus(ctx, classID, isActive); err != nil {
+			return apiDiagnostics(err, classAttributes(d))
 		}
 	}
 
@@ -276,66 +247,124 @@ func resourceClassUpdate(ctx context.Context, d *schema.ResourceData, m interfac
 func resourceClassDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
 
-	client := m.(*Client)
-	classID := d.Id()
+	client := m.(*api.Client)
 
-	url := fmt.Sprintf("%s/admin/batchs/%s/", client.APIURL, classID)
-	req, err := http.NewRequest("DELETE", url, nil)
+	classID, err := strconv.Atoi(d.Id())
 	if err != nil {
-		return diag.FromErr(err)
+		return apiDiagnostics(err, classAttributes(d))
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+client.AuthToken)
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return diag.FromErr(err)
-	}
-	defer resp.Body.Close()
-
-	// Handle 204 No Content status as a successful deletion
-	if resp.StatusCode != http.StatusNoContent {
-		return diag.Errorf("failed to delete class, status: %s", resp.Status)
+	// A class that is already gone counts as deleted.
+	if err := client.DeleteBatch(ctx, classID); err != nil && !errors.Is(err, api.ErrNotFound) {
+		return apiDiagnostics(err, classAttributes(d))
 	}
 
 	d.SetId("")
 	return diags
 }
 
-func changeClassStatus(client *Client, classID int, isActive bool) error {
-	url := fmt.Sprintf("%s/admin/batchs/%d/change_status/", client.APIURL, classID)
-	payload := map[string]interface{}{
-		"is_broadcast": false,
-		"is_active":    isActive,
+// resourceClassImport accepts either a batch ID or "<course title>/<class
+// name>". The course title is split off at the last slash, so it may
+// contain slashes itself.
+func resourceClassImport(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	client := m.(*api.Client)
+	importID := d.Id()
+
+	d.Set("warn_past_start_date", false)
+
+	if _, err := strconv.Atoi(importID); err == nil {
+		return []*schema.ResourceData{d}, nil
 	}
 
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return err
+	i := strings.LastIndex(importID, "/")
+	if i <= 0 || i == len(importID)-1 {
+		return nil, fmt.Errorf("invalid import ID %q, expected <batch_id> or <course title>/<class name>", importID)
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+	_, batchID, err := resolveClass(ctx, client, importID[:i], importID[i+1:])
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	d.SetId(strconv.Itoa(batchID))
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// classAttributes maps the fields of batch requests to the attributes of
+// adinusa_class.
+func classAttributes(d *schema.ResourceData) map[string]string {
+	course := "course_name"
+	if isConfigured(d, "course_id") {
+		course = "course_id"
+	}
+
+	return map[string]string{
+		"batch":          "class_name",
+		"start_date":     "start_date",
+		"end_date":       "end_date",
+		"group_type":     "group_type",
+		"is_last_batch":  "is_last_batch",
+		"is_enroll_pass": "is_enroll_pass",
+		"is_certificate": "is_certificate",
+		"is_schedule":    "is_schedule",
+		"is_active":      "is_active",
+		"course":         course,
+	}
+}
+
+// classCourseID returns the configured course_id, or looks the course up by
+// course_name when course_id is not configured.
+func classCourseID(ctx context.Context, client *api.Client, d *schema.ResourceData) (int, error) {
+	if isConfigured(d, "course_id") {
+		return d.Get("course_id").(int), nil
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+client.AuthToken)
+	return getCourseIDByName(ctx, client, d.Get("course_name").(string))
+}
 
-	resp, err := client.Do(req)
+// setClassData sets the attributes shared by the adinusa_class resource and
+// data source from a batch.
+func setClassData(d *schema.ResourceData, batch *api.Batch) error {
+	groupTypeStr, err := convertGroupTypeToString(batch.GroupType)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to change class status, status: %s", resp.Status)
-	}
+	d.Set("class_name", batch.Name)
+	d.Set("course_name", batch.Course.Title)
+	d.Set("start_date", normalizeDate(batch.StartDate))
+	d.Set("end_date", normalizeDate(batch.EndDate))
+	d.Set("group_type", groupTypeStr)
+	d.Set("is_last_batch", batch.IsLastBatch)
+	d.Set("is_enroll_pass", batch.IsEnrollPass)
+	d.Set("is_certificate", batch.IsCertificate)
+	d.Set("is_schedule", batch.IsSchedule)
+	d.Set("is_active", batch.IsActive)
 
 	return nil
 }
 
+// expandClass builds the API payload of a class from its configuration.
+func expandClass(d *schema.ResourceData, courseID int) (api.BatchRequest, error) {
+	groupType, err := convertGroupTypeToNumber(d.Get("group_type").(string))
+	if err != nil {
+		return api.BatchRequest{}, err
+	}
+
+	return api.BatchRequest{
+		Name:          d.Get("class_name").(string),
+		StartDate:     d.Get("start_date").(string),
+		EndDate:       d.Get("end_date").(string),
+		GroupType:     groupType,
+		IsLastBatch:   d.Get("is_last_batch").(bool),
+		IsEnrollPass:  d.Get("is_enroll_pass").(bool),
+		IsCertificate: d.Get("is_certificate").(bool),
+		IsSchedule:    d.Get("is_schedule").(bool),
+		CourseID:      courseID,
+	}, nil
+}
+
 func convertGroupTypeToNumber(groupTypeStr string) (int, error) {
 	switch groupTypeStr {
 	case "internal":
@@ -356,4 +385,4 @@ func convertGroupTypeToString(groupType int) (string, error) {
 	default:
 		return "", fmt.Errorf("invalid group_type: %d", groupType)
 	}
-}
\ No newline at end of file
+}
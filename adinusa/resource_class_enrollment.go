@@ -0,0 +1,211 @@
+package adinusa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-cty/cty"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	"terraform-provider-adinusa/adinusa/api"
+)
+
+// resourceClassEnrollment manages the enrollment of a single user. Unlike
+// adinusa_enroll_user it leaves every other user of the class alone.
+func resourceClassEnrollment() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceClassEnrollmentCreate,
+		ReadContext:   resourceClassEnrollmentRead,
+		DeleteContext: resourceClassEnrollmentDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: schema.ImportStatePassthroughContext,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(5 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"course_name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"class_name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"username": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+				StateFunc: func(v interface{}) string {
+					return strings.TrimSpace(v.(string))
+				},
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return usernameKey(old) == usernameKey(new)
+				},
+			},
+		},
+	}
+}
+
+func resourceClassEnrollmentCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	client := m.(*api.Client)
+	courseName := d.Get("course_name").(string)
+	className := d.Get("class_name").(string)
+	username := strings.TrimSpace(d.Get("username").(string))
+
+	_, batchID, err := resolveClass(ctx, client, courseName, className)
+	if err != nil {
+		return apiDiagnostics(err, classEnrollmentAttributes)
+	}
+
+	// Enroll User
+	result, err := client.EnrollUsers(ctx, api.Enrollment{
+		BatchID:   batchID,
+		Usernames: []string{username},
+	})
+	if err != nil {
+		return apiDiagnostics(err, classEnrollmentAttributes)
+	}
+
+	if len(result.Failed) > 0 {
+		return diag.Diagnostics{{
+			Severity:      diag.Error,
+			Summary:       fmt.Sprintf("Failed to enroll user '%s'", username),
+			Detail:        result.Failed[0].Reason,
+			AttributePath: cty.GetAttrPath("username"),
+		}}
+	}
+
+	d.SetId(classEnrollmentID(batchID, username))
+
+	return diags
+}
+
+func resourceClassEnrollmentRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+
+	batchID, username, err := parseClassEnrollmentID(d.Id())
+	if err != nil {
+		return apiDiagnostics(err, classEnrollmentAttributes)
+	}
+
+	batch, err := client.GetBatch(ctx, batchID)
+	if errors.Is(err, api.ErrNotFound) {
+		log.Printf("[WARN] Class %d not found, removing enrollment %s from state", batchID, d.Id())
+		d.SetId("")
+		return nil
+	}
+	if err != nil {
+		return apiDiagnostics(err, classEnrollmentAttributes)
+	}
+
+	courseID, err := getBatchCourseID(ctx, client, batch)
+	if err != nil {
+		return apiDiagnostics(err, classEnrollmentAttributes)
+	}
+
+	// Check User Enrollment
+	statuses, err := client.CheckUsers(ctx, api.Enrollment{
+		CourseID:  courseID,
+		BatchID:   batchID,
+		Usernames: []string{username},
+	})
+	if err != nil {
+		return apiDiagnostics(err, classEnrollmentAttributes)
+	}
+
+	enrolled := false
+	for _, status := range statuses {
+		if usernameKey(status.Username) == usernameKey(username) && status.IsEnrolled {
+			enrolled = true
+		}
+	}
+
+	if !enrolled {
+		log.Printf("[WARN] User %s is not enrolled in class %d, removing from state", username, batchID)
+		d.SetId("")
+		return nil
+	}
+
+	d.Set("course_name", batch.Course.Title)
+	d.Set("class_name", batch.Name)
+	d.Set("username", username)
+
+	return nil
+}
+
+func resourceClassEnrollmentDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	client := m.(*api.Client)
+
+	batchID, username, err := parseClassEnrollmentID(d.Id())
+	if err != nil {
+		return apiDiagnostics(err, classEnrollmentAttributes)
+	}
+
+	batch, err := client.GetBatch(ctx, batchID)
+	if err == nil {
+		var courseID int
+		courseID, err = getBatchCourseID(ctx, client, batch)
+		if err == nil {
+			// Revoke User
+			err = client.RevokeUsers(ctx, api.Enrollment{
+				CourseID:  courseID,
+				BatchID:   batchID,
+				Usernames: []string{username},
+			})
+		}
+	}
+
+	// Nothing is left to revoke when the course or class is gone.
+	if err != nil && !errors.Is(err, api.ErrNotFound) {
+		return apiDiagnostics(err, classEnrollmentAttributes)
+	}
+
+	d.SetId("")
+
+	return diags
+}
+
+// classEnrollmentAttributes maps the fields of enrollment requests to the
+// attributes of adinusa_class_enrollment.
+var classEnrollmentAttributes = map[string]string{
+	"usernames": "username",
+	"username":  "username",
+	"batch_id":  "class_name",
+	"course_id": "course_name",
+}
+
+// classEnrollmentID returns the ID of an adinusa_class_enrollment resource.
+func classEnrollmentID(batchID int, username string) string {
+	return fmt.Sprintf("%d/%s", batchID, username)
+}
+
+func parseClassEnrollmentID(id string) (int, string, error) {
+	parts := strings.SplitN(id, "/", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return 0, "", fmt.Errorf("invalid ID %q, expected <batch_id>/<username>", id)
+	}
+
+	batchID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid batch ID in %q: %v", id, err)
+	}
+
+	return batchID, parts[1], nil
+}
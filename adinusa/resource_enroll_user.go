@@ -1,15 +1,28 @@
 package adinusa
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/go-cty/cty"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/validation"
+
+	"terraform-provider-adinusa/adinusa/api"
+)
+
+// Values of validate_users.
+const (
+	validateUsersOff   = "off"
+	validateUsersWarn  = "warn"
+	validateUsersError = "error"
 )
 
 func resourceEnrollUser() *schema.Resource {
@@ -18,22 +31,76 @@ func resourceEnrollUser() *schema.Resource {
 		ReadContext:   resourceEnrollUserRead,
 		UpdateContext: resourceEnrollUserUpdate,
 		DeleteContext: resourceEnrollUserDelete,
+		Importer: &schema.ResourceImporter{
+			StateContext: resourceEnrollUserImport,
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(20 * time.Minute),
+			Read:   schema.DefaultTimeout(5 * time.Minute),
+			Update: schema.DefaultTimeout(20 * time.Minute),
+			Delete: schema.DefaultTimeout(20 * time.Minute),
+		},
+
+		SchemaVersion: 1,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Version: 0,
+				Type:    resourceEnrollUserV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceEnrollUserStateUpgradeV0,
+			},
+		},
 
 		Schema: map[string]*schema.Schema{
 			"usernames": {
-				Type:     schema.TypeList,
+				Type:     schema.TypeSet,
 				Elem:     &schema.Schema{Type: schema.TypeString},
+				Set:      hashUsername,
 				Required: true,
 			},
+			"class_id": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				ValidateFunc:  validation.StringMatch(integerRegexp, "must be a class (batch) ID"),
+				ExactlyOneOf:  []string{"class_id", "class_name"},
+				ConflictsWith: []string{"course_name", "class_name"},
+			},
 			"course_name": {
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:         schema.TypeString,
+				Optional:     true,
+				RequiredWith: []string{"class_name"},
 			},
 			"class_name": {
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:         schema.TypeString,
+				Optional:     true,
+				RequiredWith: []string{"course_name"},
+			},
+			"transfer_in_place": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+			"authoritative": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"lowercase_usernames": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+			"validate_users": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      validateUsersOff,
+				ValidateFunc: validation.StringInSlice([]string{validateUsersOff, validateUsersWarn, validateUsersError}, false),
+			},
+			"batch_size": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Default:      100,
+				ValidateFunc: validation.IntAtLeast(1),
 			},
 		},
 		CustomizeDiff: resourceEnrollUserCustomizeDiff,
@@ -41,340 +108,698 @@ func resourceEnrollUser() *schema.Resource {
 }
 
 func resourceEnrollUserCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
-	client := m.(*Client)
-	courseName := d.Get("course_name").(string)
-	className := d.Get("class_name").(string)
-
-	// Get Course ID
-	courseID, err := getCourseIDByName(client, courseName)
-	if err != nil {
-		return fmt.Errorf("failed to get course ID: %v", err)
-	}
-
-	// Check if Batch ID exists
-	_, err = getBatchIDByClass(client, courseID, className, courseName)
-	if err != nil {
-		return fmt.Errorf("batch '%s' not found for course '%s'", className, courseName)
+	client := m.(*api.Client)
+
+	// Without an in-place transfer, moving to another class revokes every
+	// user and enrolls them again.
+	if !d.Get("transfer_in_place").(bool) {
+		for _, key := range []string{"class_id", "course_name", "class_name"} {
+			if d.HasChange(key) {
+				if err := d.ForceNew(key); err != nil {
+					return err
+				}
+			}
+		}
 	}
 
-	return nil
-}
-
-func resourceEnrollUserCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	var diags diag.Diagnostics
-
-	client := m.(*Client)
-	usernames := getStringListFromSchema(d.Get("usernames").([]interface{}))
-	courseName := d.Get("course_name").(string)
-	className := d.Get("class_name").(string)
+	// The class may be created in the same apply, in which case its ID or
+	// names are not known yet. New names of an existing enrollment moved in
+	// place are not checked either, since the class may be renamed in the
+	// same apply; a missing class then fails the transfer instead. A
+	// replacement revokes the roster first, so its class is still checked.
+	renamed := d.Id() != "" && d.Get("transfer_in_place").(bool) && d.HasChanges("course_name", "class_name")
+	if isConfigured(d, "class_id") {
+		if d.NewValueKnown("class_id") {
+			// Check if Batch exists
+			classID := d.Get("class_id").(string)
+			batchID, _ := strconv.Atoi(classID)
+			if _, err := client.GetBatch(ctx, batchID); err != nil {
+				return fmt.Errorf("batch %s not found: %v", classID, err)
+			}
+		}
+	} else if !renamed && d.NewValueKnown("course_name") && d.NewValueKnown("class_name") {
+		courseName := d.Get("course_name").(string)
+		className := d.Get("class_name").(string)
+
+		// Get Course ID
+		courseID, err := getCourseIDByName(ctx, client, courseName)
+		if err != nil {
+			return fmt.Errorf("failed to get course ID: %v", err)
+		}
 
-	// Get Course ID
-	courseID, err := getCourseIDByName(client, courseName)
-	if err != nil {
-		return diag.FromErr(err)
+		// Check if Batch ID exists
+		_, err = getBatchIDByClass(ctx, client, courseID, className, courseName)
+		if err != nil {
+			return fmt.Errorf("batch '%s' not found for course '%s'", className, courseName)
+		}
 	}
 
-	// Get Batch ID
-	batchID, err := getBatchIDByClass(client, courseID, className, courseName)
-	if err != nil {
-		return diag.FromErr(err)
+	// Check that the users about to be enrolled exist. Warnings cannot be
+	// shown at plan time, so warn mode only checks them when enrolling.
+	if d.Get("validate_users").(string) != validateUsersError || !d.NewValueKnown("usernames") || !d.NewValueKnown("lowercase_usernames") {
+		return nil
 	}
 
-	// Enroll Users
-	enrollURL := client.APIURL + "/admin/enrollment/enroll_users/"
-	payload := map[string]interface{}{
-		"batch_id":  batchID,
-		"usernames": usernames,
-	}
+	lowercase := d.Get("lowercase_usernames").(bool)
+	old, new := d.GetChange("usernames")
+	toEnroll := difference(normalizeUsernames(new.(*schema.Set).List(), lowercase), normalizeUsernames(old.(*schema.Set).List(), lowercase))
 
-	body, err := json.Marshal(payload)
+	unknown, err := findUnknownUsers(ctx, client, toEnroll)
 	if err != nil {
-		return diag.FromErr(err)
+		return err
 	}
 
-	req, err := http.NewRequest("POST", enrollURL, bytes.NewBuffer(body))
-	if err != nil {
-		return diag.FromErr(err)
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown Adinusa users: %s", strings.Join(unknown, ", "))
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+client.AuthToken)
+	return nil
+}
 
-	resp, err := client.Do(req)
+func resourceEnrollUserCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+	usernames := getUsernamesFromSchema(d)
+	courseID, batchID, err := classOf(ctx, client, d)
 	if err != nil {
-		return diag.FromErr(err)
+		return apiDiagnostics(err, enrollmentAttributes(d))
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return diag.Errorf("failed to enroll users, status: %s", resp.Status)
+	// Enroll Users
+	enrolled, diags := enrollUsers(ctx, d, m, usernames)
+	if len(enrolled) == 0 && len(usernames) > 0 {
+		if !diags.HasError() {
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Error,
+				Summary:       "No users were enrolled",
+				Detail:        "Adinusa rejected every user in usernames, see the warnings for the reasons.",
+				AttributePath: cty.GetAttrPath("usernames"),
+			})
+		}
+		return diags
 	}
 
-	d.SetId(strings.Join(usernames, ","))
+	// Only the users that were enrolled are recorded, the others are
+	// planned again on the next run. Failing the create instead would taint
+	// the resource, and the next apply would revoke everyone enrolled so far.
+	d.SetId(enrollmentID(courseID, batchID))
+	d.Set("usernames", keepUsernames(d, enrolled))
+
+	for i := range diags {
+		if diags[i].Severity == diag.Error {
+			diags[i].Severity = diag.Warning
+			diags[i].Detail += "\nThe users that were not enrolled are planned again on the next run."
+		}
+	}
 
+	// In authoritative mode, users enrolled outside Terraform are found by
+	// the next refresh and revoked only once a plan has shown them.
 	return diags
 }
 
 func resourceEnrollUserRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
 
-	client := m.(*Client)
-	usernames := getStringListFromSchema(d.Get("usernames").([]interface{}))
-	courseName := d.Get("course_name").(string)
-	className := d.Get("class_name").(string)
-
-	// Get Course ID
-	courseID, err := getCourseIDByName(client, courseName)
-	if err != nil {
-		return diag.FromErr(err)
+	client := m.(*api.Client)
+	usernames := getUsernamesFromSchema(d)
+	courseID, batchID, err := classOf(ctx, client, d)
+	if errors.Is(err, api.ErrNotFound) {
+		log.Printf("[WARN] %v, removing enrollment %s from state", err, d.Id())
+		d.SetId("")
+		return nil
 	}
-
-	// Get Batch ID
-	batchID, err := getBatchIDByClass(client, courseID, className, courseName)
 	if err != nil {
-		return diag.FromErr(err)
+		return apiDiagnostics(err, enrollmentAttributes(d))
 	}
 
-	// Check User Enrollment
-	checkURL := client.APIURL + "/admin/enrollment/check_user/"
-	payload := map[string]interface{}{
-		"course_id": courseID,
-		"batch_id":  batchID,
-		"usernames": usernames,
-	}
-
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return diag.FromErr(err)
-	}
+	// In authoritative mode every enrolled user is reported, so users
+	// enrolled outside Terraform show up as drift and are revoked.
+	// Otherwise only the configured users are checked.
+	authoritative := d.Get("authoritative").(bool)
 
-	req, err := http.NewRequest("POST", checkURL, bytes.NewBuffer(body))
-	if err != nil {
-		return diag.FromErr(err)
-	}
+	enrolled := make(map[string]struct{})
+	var unmanaged []string
+	if authoritative {
+		// The class was just found, so a 404 here is not a deleted class.
+		enrollees, err := client.ListEnrollments(ctx, batchID)
+		if err != nil {
+			return apiDiagnostics(err, enrollmentAttributes(d))
+		}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+client.AuthToken)
+		for _, enrollee := range enrollees {
+			enrolled[usernameKey(enrollee.Username)] = struct{}{}
+		}
+		unmanaged = difference(usernamesOf(enrollees), usernames)
+	} else {
+		// Check User Enrollment
+		statuses, err := client.CheckUsers(ctx, api.Enrollment{
+			CourseID:  courseID,
+			BatchID:   batchID,
+			Usernames: usernames,
+		})
+		if err != nil {
+			return apiDiagnostics(err, enrollmentAttributes(d))
+		}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return diag.FromErr(err)
+		for _, status := range statuses {
+			if status.IsEnrolled {
+				enrolled[usernameKey(status.Username)] = struct{}{}
+			}
+		}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return diag.Errorf("failed to check user enrollment, status: %s", resp.Status)
+	// Keep only the users that are still enrolled, so users revoked outside
+	// Terraform show up as drift and are enrolled again. Users keep the
+	// spelling they have in the configuration.
+	var enrolledUsernames []string
+	for _, v := range d.Get("usernames").(*schema.Set).List() {
+		if _, ok := enrolled[usernameKey(v.(string))]; ok {
+			enrolledUsernames = append(enrolledUsernames, v.(string))
+		}
 	}
 
-	d.SetId(strings.Join(usernames, ","))
+	d.Set("usernames", append(enrolledUsernames, unmanaged...))
+
+	// Also moves resources created with the former username based ID to
+	// the course and batch based one.
+	d.SetId(enrollmentID(courseID, batchID))
 
 	return diags
 }
 
 func resourceEnrollUserUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	if d.HasChange("usernames") {
+	var diags diag.Diagnostics
+
+	moved, err := classMoved(ctx, d, m)
+	if err != nil {
+		return apiDiagnostics(err, enrollmentAttributes(d))
+	}
+
+	if moved {
+		diags = append(diags, transferUsers(ctx, d, m)...)
+		if diags.HasError() {
+			return diags
+		}
+	} else if d.HasChange("usernames") {
 		old, new := d.GetChange("usernames")
-		oldUsernames := getStringListFromSchema(old.([]interface{}))
-		newUsernames := getStringListFromSchema(new.([]interface{}))
+		lowercase := d.Get("lowercase_usernames").(bool)
+		oldUsernames := normalizeUsernames(old.(*schema.Set).List(), lowercase)
+		newUsernames := normalizeUsernames(new.(*schema.Set).List(), lowercase)
 
 		toRevoke := difference(oldUsernames, newUsernames)
 		if len(toRevoke) > 0 {
-			diags := revokeUsers(ctx, d, m, toRevoke)
-			if len(diags) > 0 {
+			diags = append(diags, revokeUsers(ctx, d, m, toRevoke)...)
+			if diags.HasError() {
+				// Keep the old users, the next refresh drops those that
+				// were revoked.
+				d.Partial(true)
 				return diags
 			}
 		}
 
 		toEnroll := difference(newUsernames, oldUsernames)
 		if len(toEnroll) > 0 {
-			diags := enrollUsers(ctx, d, m, toEnroll)
-			if len(diags) > 0 {
+			// Users that could not be enrolled are dropped from the state by
+			// the read below.
+			enrolled, enrollDiags := enrollUsers(ctx, d, m, toEnroll)
+			diags = append(diags, enrollDiags...)
+			if diags.HasError() {
+				// Record the remaining old users and those enrolled so far,
+				// the others are planned again on the next run.
+				var recorded []string
+				for _, v := range old.(*schema.Set).List() {
+					if len(difference([]string{v.(string)}, toRevoke)) > 0 {
+						recorded = append(recorded, v.(string))
+					}
+				}
+				d.Set("usernames", append(recorded, keepUsernames(d, enrolled)...))
 				return diags
 			}
 		}
 	}
-	return resourceEnrollUserRead(ctx, d, m)
+
+	return append(diags, resourceEnrollUserRead(ctx, d, m)...)
 }
 
 func resourceEnrollUserDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	var diags diag.Diagnostics
 
-	client := m.(*Client)
-	usernames := getStringListFromSchema(d.Get("usernames").([]interface{}))
-	courseName := d.Get("course_name").(string)
-	className := d.Get("class_name").(string)
+	client := m.(*api.Client)
+	usernames := getUsernamesFromSchema(d)
+	courseID, batchID, err := classOf(ctx, client, d)
+	if err == nil {
+		// Revoke Users
+		err = revokeFromClass(ctx, client, courseID, batchID, usernames, d.Get("batch_size").(int))
+	}
+
+	// Nothing is left to revoke when the course or class is gone.
+	if err != nil && !errors.Is(err, api.ErrNotFound) {
+		return apiDiagnostics(err, enrollmentAttributes(d))
+	}
+
+	d.SetId("") // Clear the resource ID to signal deletion
+
+	return diags
+}
+
+// resourceEnrollUserImport imports the current enrollees of a class from
+// an ID of the form "<course_id>/<batch_id>".
+func resourceEnrollUserImport(ctx context.Context, d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+	client := m.(*api.Client)
 
-	// Get Course ID
-	courseID, err := getCourseIDByName(client, courseName)
+	courseID, batchID, err := parseEnrollmentID(d.Id())
 	if err != nil {
-		return diag.FromErr(err)
+		return nil, err
 	}
 
-	// Get Batch ID
-	batchID, err := getBatchIDByClass(client, courseID, className, courseName)
+	batch, err := client.GetBatch(ctx, batchID)
 	if err != nil {
-		return diag.FromErr(err)
+		return nil, err
 	}
 
-	// Revoke Users
-	revokeURL := client.APIURL + "/admin/enrollment/revoke_users/"
-	payload := map[string]interface{}{
-		"course_id": courseID,
-		"batch_id":  batchID,
-		"usernames": usernames,
+	batchCourseID, err := getBatchCourseID(ctx, client, batch)
+	if err != nil {
+		return nil, err
+	}
+	if batchCourseID != courseID {
+		return nil, fmt.Errorf("batch %d does not belong to course %d", batchID, courseID)
+	}
+
+	enrollees, err := client.ListEnrollments(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	d.Set("course_name", batch.Course.Title)
+	d.Set("class_name", batch.Name)
+	d.Set("usernames", usernamesOf(enrollees))
+	d.Set("authoritative", false)
+	d.Set("lowercase_usernames", false)
+	d.Set("batch_size", 100)
+	d.Set("validate_users", validateUsersOff)
+	d.Set("transfer_in_place", true)
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// classOf returns the course and batch IDs of the configured class.
+func classOf(ctx context.Context, client *api.Client, d *schema.ResourceData) (int, int, error) {
+	return resolveEnrollmentClass(ctx, client, d.Get("class_id").(string), d.Get("course_name").(string), d.Get("class_name").(string))
+}
+
+// resolveEnrollmentClass returns the course and batch IDs of a class given
+// either by its batch ID or by the names of its course and itself.
+func resolveEnrollmentClass(ctx context.Context, client *api.Client, classID string, courseName string, className string) (int, int, error) {
+	if classID == "" {
+		return resolveClass(ctx, client, courseName, className)
+	}
+
+	batchID, err := strconv.Atoi(classID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid class ID %q: %v", classID, err)
 	}
 
-	body, err := json.Marshal(payload)
+	batch, err := client.GetBatch(ctx, batchID)
 	if err != nil {
-		return diag.FromErr(err)
+		return 0, 0, err
 	}
 
-	req, err := http.NewRequest("POST", revokeURL, bytes.NewBuffer(body))
+	courseID, err := getBatchCourseID(ctx, client, batch)
 	if err != nil {
-		return diag.FromErr(err)
+		return 0, 0, err
+	}
+
+	return courseID, batchID, nil
+}
+
+// enrollmentAttributes maps the fields of enrollment requests to the
+// attributes of adinusa_enroll_user.
+func enrollmentAttributes(d *schema.ResourceData) map[string]string {
+	class, course := "class_name", "course_name"
+	if d.Get("class_id").(string) != "" {
+		class, course = "class_id", "class_id"
+	}
+
+	return map[string]string{
+		"usernames": "usernames",
+		"username":  "usernames",
+		"batch_id":  class,
+		"course_id": course,
+	}
+}
+
+// enrollmentID returns the ID of an adinusa_enroll_user resource.
+func enrollmentID(courseID int, batchID int) string {
+	return fmt.Sprintf("%d/%d", courseID, batchID)
+}
+
+func parseEnrollmentID(id string) (int, int, error) {
+	parts := strings.Split(id, "/")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid ID %q, expected <course_id>/<batch_id>", id)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+client.AuthToken)
+	courseID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid course ID in %q: %v", id, err)
+	}
 
-	resp, err := client.Do(req)
+	batchID, err := strconv.Atoi(parts[1])
 	if err != nil {
-		return diag.FromErr(err)
+		return 0, 0, fmt.Errorf("invalid batch ID in %q: %v", id, err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return diag.Errorf("failed to revoke users, status: %s", resp.Status)
+	return courseID, batchID, nil
+}
+
+func usernamesOf(enrollees []api.Enrollee) []string {
+	usernames := make([]string, 0, len(enrollees))
+	for _, enrollee := range enrollees {
+		usernames = append(usernames, enrollee.Username)
 	}
+	return usernames
+}
 
-	d.SetId("") // Clear the resource ID to signal deletion
+// usernameKey identifies a user regardless of surrounding whitespace and
+// case, so "Alice" and " alice" are the same user.
+func usernameKey(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
 
-	return diags
+func hashUsername(v interface{}) int {
+	return schema.HashString(usernameKey(v.(string)))
 }
 
-func getStringListFromSchema(input []interface{}) []string {
+// normalizeUsernames trims usernames, lowercases them when lowercase is set
+// and drops duplicates, ready to be sent to the API.
+func normalizeUsernames(input []interface{}, lowercase bool) []string {
+	seen := make(map[string]struct{}, len(input))
 	var result []string
 	for _, v := range input {
-		result = append(result, v.(string))
+		username := strings.TrimSpace(v.(string))
+		if lowercase {
+			username = strings.ToLower(username)
+		}
+
+		if _, ok := seen[usernameKey(username)]; ok {
+			continue
+		}
+		seen[usernameKey(username)] = struct{}{}
+		result = append(result, username)
 	}
 	return result
 }
 
+func getUsernamesFromSchema(d *schema.ResourceData) []string {
+	return normalizeUsernames(d.Get("usernames").(*schema.Set).List(), d.Get("lowercase_usernames").(bool))
+}
+
 func difference(a, b []string) []string {
 	mb := make(map[string]struct{}, len(b))
 	for _, x := range b {
-		mb[x] = struct{}{}
+		mb[usernameKey(x)] = struct{}{}
 	}
 	var diff []string
 	for _, x := range a {
-		if _, found := mb[x]; !found {
+		if _, found := mb[usernameKey(x)]; !found {
 			diff = append(diff, x)
 		}
 	}
 	return diff
 }
 
-func enrollUsers(ctx context.Context, d *schema.ResourceData, m interface{}, usernames []string) diag.Diagnostics {
+// enrollUsers enrolls users in chunks of batch_size and returns the users
+// that were enrolled. Users rejected by Adinusa are reported as warnings,
+// since the other users of their chunk are enrolled anyway. When Adinusa
+// rejects a whole chunk, the chunk is split until the rejected users are
+// found.
+func enrollUsers(ctx context.Context, d *schema.ResourceData, m interface{}, usernames []string) ([]string, diag.Diagnostics) {
 	var diags diag.Diagnostics
 
-	client := m.(*Client)
-	courseName := d.Get("course_name").(string)
-	className := d.Get("class_name").(string)
-
-	// Get Course ID
-	courseID, err := getCourseIDByName(client, courseName)
+	client := m.(*api.Client)
+	_, batchID, err := classOf(ctx, client, d)
 	if err != nil {
-		return diag.FromErr(err)
+		return nil, apiDiagnostics(err, enrollmentAttributes(d))
 	}
 
-	// Get Batch ID
-	batchID, err := getBatchIDByClass(client, courseID, className, courseName)
-	if err != nil {
-		return diag.FromErr(err)
+	// Unknown users are reported at plan time in error mode, so only
+	// warnings are left to report here.
+	if d.Get("validate_users").(string) == validateUsersWarn {
+		unknown, err := findUnknownUsers(ctx, client, usernames)
+		if err != nil {
+			return nil, apiDiagnostics(err, enrollmentAttributes(d))
+		}
+
+		if len(unknown) > 0 {
+			diags = append(diags, diag.Diagnostic{
+				Severity:      diag.Warning,
+				Summary:       fmt.Sprintf("%d users do not exist in Adinusa", len(unknown)),
+				Detail:        "No Adinusa account was found for: " + strings.Join(unknown, ", "),
+				AttributePath: cty.GetAttrPath("usernames"),
+			})
+		}
 	}
 
-	// Enroll Users
-	enrollURL := client.APIURL + "/admin/enrollment/enroll_users/"
-	payload := map[string]interface{}{
-		"batch_id":  batchID,
-		"usernames": usernames,
+	var enrolled []string
+	var failed []api.EnrollFailure
+
+	pending := chunkUsernames(usernames, d.Get("batch_size").(int))
+	for len(pending) > 0 {
+		chunk := pending[0]
+		pending = pending[1:]
+
+		// Enroll Users
+		result, err := client.EnrollUsers(ctx, api.Enrollment{
+			BatchID:   batchID,
+			Usernames: chunk,
+		})
+		if reasons := usernameErrors(err); len(reasons) > 0 {
+			if len(chunk) > 1 {
+				half := len(chunk) / 2
+				pending = append([][]string{chunk[:half], chunk[half:]}, pending...)
+				continue
+			}
+			failed = append(failed, api.EnrollFailure{Username: chunk[0], Reason: strings.Join(reasons, " ")})
+			continue
+		}
+		if err != nil {
+			for _, errDiag := range apiDiagnostics(err, enrollmentAttributes(d)) {
+				errDiag.Summary = fmt.Sprintf("Failed to enroll %d users", len(chunk)+countUsernames(pending))
+				diags = append(diags, errDiag)
+			}
+			break
+		}
+
+		enrolled = append(enrolled, result.Enrolled...)
+		failed = append(failed, result.Failed...)
 	}
 
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return diag.FromErr(err)
+	if len(failed) > 0 {
+		details := make([]string, 0, len(failed))
+		for _, failure := range failed {
+			details = append(details, fmt.Sprintf("%s: %s", failure.Username, failure.Reason))
+		}
+
+		diags = append(diags, diag.Diagnostic{
+			Severity:      diag.Warning,
+			Summary:       fmt.Sprintf("%d users could not be enrolled", len(failed)),
+			Detail:        "These users were not enrolled and are not recorded in the state:\n" + strings.Join(details, "\n"),
+			AttributePath: cty.GetAttrPath("usernames"),
+		})
 	}
 
-	req, err := http.NewRequest("POST", enrollURL, bytes.NewBuffer(body))
-	if err != nil {
-		return diag.FromErr(err)
+	return enrolled, diags
+}
+
+// usernameErrors returns the messages of an enrollment that Adinusa rejected
+// because of its usernames. It returns nil when the request was rejected
+// for another reason, such as a closed class, which splitting the chunk
+// would not fix.
+func usernameErrors(err error) []string {
+	var apiErr *api.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		return nil
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+client.AuthToken)
+	return append(apiErr.FieldErrors["usernames"], apiErr.FieldErrors["username"]...)
+}
 
-	resp, err := client.Do(req)
+// findUnknownUsers returns the usernames without an Adinusa account.
+func findUnknownUsers(ctx context.Context, client *api.Client, usernames []string) ([]string, error) {
+	if len(usernames) == 0 {
+		return nil, nil
+	}
+
+	exists, err := client.UsersExist(ctx, usernames)
 	if err != nil {
-		return diag.FromErr(err)
+		return nil, err
+	}
+
+	var unknown []string
+	for _, username := range usernames {
+		if !exists[username] {
+			unknown = append(unknown, username)
+		}
 	}
-	defer resp.Body.Close()
+	return unknown, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return diag.Errorf("failed to enroll users, status: %s", resp.Status)
+// keepUsernames returns the configured usernames, as spelled in the
+// configuration, that are among the given users.
+func keepUsernames(d *schema.ResourceData, usernames []string) []string {
+	keep := make(map[string]struct{}, len(usernames))
+	for _, username := range usernames {
+		keep[usernameKey(username)] = struct{}{}
 	}
 
-	return diags
+	var result []string
+	for _, v := range d.Get("usernames").(*schema.Set).List() {
+		if _, ok := keep[usernameKey(v.(string))]; ok {
+			result = append(result, v.(string))
+		}
+	}
+	return result
+}
+
+func chunkUsernames(usernames []string, size int) [][]string {
+	var chunks [][]string
+	for size > 0 && len(usernames) > size {
+		chunks = append(chunks, usernames[:size])
+		usernames = usernames[size:]
+	}
+	if len(usernames) > 0 {
+		chunks = append(chunks, usernames)
+	}
+	return chunks
+}
+
+func countUsernames(chunks [][]string) int {
+	n := 0
+	for _, chunk := range chunks {
+		n += len(chunk)
+	}
+	return n
 }
 
 func revokeUsers(ctx context.Context, d *schema.ResourceData, m interface{}, usernames []string) diag.Diagnostics {
 	var diags diag.Diagnostics
 
-	client := m.(*Client)
-	courseName := d.Get("course_name").(string)
-	className := d.Get("class_name").(string)
-
-	// Get Course ID
-	courseID, err := getCourseIDByName(client, courseName)
+	client := m.(*api.Client)
+	courseID, batchID, err := classOf(ctx, client, d)
 	if err != nil {
-		return diag.FromErr(err)
+		return apiDiagnostics(err, enrollmentAttributes(d))
 	}
 
-	// Get Batch ID
-	batchID, err := getBatchIDByClass(client, courseID, className, courseName)
-	if err != nil {
-		return diag.FromErr(err)
+	// Revoke Users
+	if err := revokeFromClass(ctx, client, courseID, batchID, usernames, d.Get("batch_size").(int)); err != nil {
+		return apiDiagnostics(err, enrollmentAttributes(d))
 	}
 
-	// Revoke Users
-	revokeURL := client.APIURL + "/admin/enrollment/revoke_users/"
-	payload := map[string]interface{}{
-		"course_id": courseID,
-		"batch_id":  batchID,
-		"usernames": usernames,
+	return diags
+}
+
+// revokeFromClass revokes users from a class in chunks of batchSize.
+func revokeFromClass(ctx context.Context, client *api.Client, courseID int, batchID int, usernames []string, batchSize int) error {
+	for _, chunk := range chunkUsernames(usernames, batchSize) {
+		err := client.RevokeUsers(ctx, api.Enrollment{
+			CourseID:  courseID,
+			BatchID:   batchID,
+			Usernames: chunk,
+		})
+		if err != nil {
+			return err
+		}
 	}
 
-	body, err := json.Marshal(payload)
+	return nil
+}
+
+// classMoved reports whether the configured class is another class than the
+// one in the state. Switching between class_id and the names of the same
+// class is not a move.
+func classMoved(ctx context.Context, d *schema.ResourceData, m interface{}) (bool, error) {
+	if !d.HasChanges("class_id", "course_name", "class_name") {
+		return false, nil
+	}
+
+	client := m.(*api.Client)
+
+	_, oldBatchID, err := previousClass(ctx, client, d)
+	if errors.Is(err, api.ErrNotFound) {
+		return true, nil
+	}
 	if err != nil {
-		return diag.FromErr(err)
+		return false, err
 	}
 
-	req, err := http.NewRequest("POST", revokeURL, bytes.NewBuffer(body))
+	_, batchID, err := classOf(ctx, client, d)
 	if err != nil {
-		return diag.FromErr(err)
+		return false, err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+client.AuthToken)
+	return batchID != oldBatchID, nil
+}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return diag.FromErr(err)
+// previousClass returns the course and batch IDs of the class in the state.
+// They are read from the resource ID, which stays valid when the class is
+// renamed in the same apply.
+func previousClass(ctx context.Context, client *api.Client, d *schema.ResourceData) (int, int, error) {
+	if courseID, batchID, err := parseEnrollmentID(d.Id()); err == nil {
+		return courseID, batchID, nil
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return diag.Errorf("failed to revoke users, status: %s", resp.Status)
+	// IDs from before the course and batch based ID
+	oldClassID, _ := d.GetChange("class_id")
+	oldCourseName, _ := d.GetChange("course_name")
+	oldClassName, _ := d.GetChange("class_name")
+
+	return resolveEnrollmentClass(ctx, client, oldClassID.(string), oldCourseName.(string), oldClassName.(string))
+}
+
+// transferUsers moves the enrollments to the newly configured class. Users
+// are enrolled in the new class before they are revoked from the old one,
+// so nobody loses access in between. Users that cannot be enrolled in the
+// new class keep their old enrollment.
+func transferUsers(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	client := m.(*api.Client)
+	lowercase := d.Get("lowercase_usernames").(bool)
+	old, new := d.GetChange("usernames")
+	oldUsernames := normalizeUsernames(old.(*schema.Set).List(), lowercase)
+	newUsernames := normalizeUsernames(new.(*schema.Set).List(), lowercase)
+
+	// Keep the old class in the state until the transfer is done, so a
+	// failed transfer is retried instead of forgotten.
+	d.Partial(true)
+
+	enrolled, diags := enrollUsers(ctx, d, m, newUsernames)
+	if diags.HasError() {
+		return diags
+	}
+
+	oldCourseID, oldBatchID, err := previousClass(ctx, client, d)
+	if err != nil && !errors.Is(err, api.ErrNotFound) {
+		return append(diags, apiDiagnostics(err, enrollmentAttributes(d))...)
 	}
 
+	// Nothing is left to revoke when the old class is gone.
+	if err == nil {
+		notMoved := difference(newUsernames, enrolled)
+		toRevoke := difference(oldUsernames, notMoved)
+
+		err = revokeFromClass(ctx, client, oldCourseID, oldBatchID, toRevoke, d.Get("batch_size").(int))
+		if err != nil && !errors.Is(err, api.ErrNotFound) {
+			return append(diags, apiDiagnostics(err, enrollmentAttributes(d))...)
+		}
+
+		if kept := difference(oldUsernames, toRevoke); len(kept) > 0 {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.Warning,
+				Summary:  fmt.Sprintf("%d users were left in class %d", len(kept), oldBatchID),
+				Detail:   "These users could not be enrolled in the new class and keep their old enrollment: " + strings.Join(kept, ", "),
+			})
+		}
+	}
+
+	d.Partial(false)
+
 	return diags
-}
\ No newline at end of file
+}
@@ -0,0 +1,62 @@
+package adinusa
+
+import (
+	"context"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+// resourceEnrollUserV0 is the schema of adinusa_enroll_user before
+// usernames became a set.
+func resourceEnrollUserV0() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"usernames": {
+				Type:     schema.TypeList,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Required: true,
+			},
+			"course_name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"class_name": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"authoritative": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+			},
+		},
+	}
+}
+
+// resourceEnrollUserStateUpgradeV0 turns the usernames list into a set by
+// trimming the names and dropping duplicates.
+func resourceEnrollUserStateUpgradeV0(ctx context.Context, rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	usernames, _ := rawState["usernames"].([]interface{})
+
+	seen := make(map[string]struct{}, len(usernames))
+	upgraded := make([]interface{}, 0, len(usernames))
+	for _, v := range usernames {
+		username, ok := v.(string)
+		if !ok {
+			continue
+		}
+		username = strings.TrimSpace(username)
+
+		if _, ok := seen[usernameKey(username)]; ok {
+			continue
+		}
+		seen[usernameKey(username)] = struct{}{}
+		upgraded = append(upgraded, username)
+	}
+
+	rawState["usernames"] = upgraded
+	return rawState, nil
+}
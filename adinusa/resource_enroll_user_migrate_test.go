@@ -0,0 +1,81 @@
+package adinusa
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestResourceEnrollUserStateUpgradeV0(t *testing.T) {
+	cases := []struct {
+		name      string
+		usernames interface{}
+		want      []interface{}
+	}{
+		{
+			name:      "unchanged",
+			usernames: []interface{}{"alice", "bob"},
+			want:      []interface{}{"alice", "bob"},
+		},
+		{
+			name:      "duplicates",
+			usernames: []interface{}{"alice", "bob", "alice"},
+			want:      []interface{}{"alice", "bob"},
+		},
+		{
+			name:      "whitespace",
+			usernames: []interface{}{" alice", "bob ", "alice"},
+			want:      []interface{}{"alice", "bob"},
+		},
+		{
+			name:      "mixed case keeps the first spelling",
+			usernames: []interface{}{"Alice", "alice", "ALICE ", "Bob"},
+			want:      []interface{}{"Alice", "Bob"},
+		},
+		{
+			name:      "empty",
+			usernames: []interface{}{},
+			want:      []interface{}{},
+		},
+		{
+			name:      "missing",
+			usernames: nil,
+			want:      []interface{}{},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			others := map[string]interface{}{
+				"id":            "12/42",
+				"course_name":   "Kubernetes Application Developer",
+				"class_name":    "K9DEV-CLASS-1",
+				"authoritative": true,
+			}
+
+			rawState := make(map[string]interface{}, len(others)+1)
+			for key, value := range others {
+				rawState[key] = value
+			}
+			if tc.usernames != nil {
+				rawState["usernames"] = tc.usernames
+			}
+
+			got, err := resourceEnrollUserStateUpgradeV0(context.Background(), rawState, nil)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got["usernames"], tc.want) {
+				t.Errorf("usernames = %#v, want %#v", got["usernames"], tc.want)
+			}
+
+			// The other attributes are carried over untouched.
+			for key, want := range others {
+				if got[key] != want {
+					t.Errorf("%s = %#v, want %#v", key, got[key], want)
+				}
+			}
+		})
+	}
+}